@@ -0,0 +1,95 @@
+/*
+Package audit records deletions (and files replaced with hard links) to a
+log file, so that it's possible to trace who removed what (and when) on
+shared machines.
+
+Records are appended to the log as JSON, one per line. Each removal is
+logged twice: once before it begins (with a "started" result), so that
+interrupted removals can still be traced, and once it has finished.
+*/
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Results used to describe the progress of a removal.
+const (
+	Started = "started"
+	Removed = "removed"
+	Linked  = "linked"
+	Failed  = "failed"
+)
+
+// Structure representing a single line in the log.
+type Record struct {
+	Time   time.Time `json:"time"`
+	User   string    `json:"user"`
+	Host   string    `json:"host"`
+	Path   string    `json:"path"`
+	Size   int64     `json:"size"`
+	Mode   string    `json:"mode"`
+	Result string    `json:"result"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Log appends records to a file, and is safe for concurrent use.
+type Log struct {
+	file  *os.File
+	user  string
+	host  string
+	mutex sync.Mutex
+}
+
+// Open opens (or creates) the log file at the specified
+// path, creating any missing parent directories.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &Log{file: file, user: strconv.Itoa(os.Getuid())}
+	if current, err := user.Current(); err == nil {
+		log.user = current.Username
+	}
+	log.host, _ = os.Hostname()
+
+	return log, nil
+}
+
+// Write appends the record to the log, filling in the time, user and
+// host, and waits for it to reach the disk before returning.
+func (log *Log) Write(record Record) error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	record.Time = time.Now()
+	record.User = log.user
+	record.Host = log.host
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = log.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return log.file.Sync()
+}
+
+// Close closes the log file.
+func (log *Log) Close() error {
+	return log.file.Close()
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAudit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Audit Suite")
+}
+
+var _ = Describe("Log", func() {
+	var log *Log
+	var path string
+	var err error
+
+	BeforeEach(func() {
+		path = "logs/audit.log"
+		log, err = Open(path)
+	})
+
+	AfterEach(func() {
+		log.Close()
+		os.RemoveAll("logs")
+	})
+
+	It("creates the log file and its directory", func() {
+		Expect(err).To(BeNil())
+		_, err = os.Stat(path)
+		Expect(err).To(BeNil())
+	})
+
+	It("appends records as lines of JSON", func() {
+		log.Write(Record{Path: "/tmp/a", Size: 6, Mode: "-rw-------", Result: Started})
+		log.Write(Record{Path: "/tmp/a", Size: 6, Mode: "-rw-------", Result: Failed, Error: "permission denied"})
+
+		data, _ := ioutil.ReadFile(path)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(HaveLen(2))
+
+		var record Record
+		Expect(json.Unmarshal([]byte(lines[1]), &record)).To(BeNil())
+		Expect(record.Path).To(Equal("/tmp/a"))
+		Expect(record.Result).To(Equal(Failed))
+		Expect(record.Error).To(Equal("permission denied"))
+		Expect(record.User).ToNot(BeEmpty())
+		Expect(record.Time).ToNot(BeZero())
+	})
+
+	It("leaves out the error when there isn't one", func() {
+		log.Write(Record{Path: "/tmp/a", Result: Removed})
+
+		data, _ := ioutil.ReadFile(path)
+		Expect(string(data)).ToNot(ContainSubstring("error"))
+	})
+
+	It("keeps existing records when reopened", func() {
+		log.Write(Record{Path: "/tmp/a", Result: Started})
+		log.Close()
+
+		log, _ = Open(path)
+		log.Write(Record{Path: "/tmp/a", Result: Removed})
+
+		data, _ := ioutil.ReadFile(path)
+		Expect(strings.Count(string(data), "\n")).To(Equal(2))
+	})
+})
@@ -0,0 +1,107 @@
+/*
+Package config loads user preferences from a JSON configuration file.
+
+The file is read from $XDG_CONFIG_HOME/purge/config.json, falling back
+to ~/.config/purge/config.json if XDG_CONFIG_HOME isn't set. A missing
+file isn't an error; the defaults are used instead.
+*/
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Structure representing the contents of the configuration file.
+type Config struct {
+	// Maps key sequences (using Vim-style names for special keys, e.g.
+	// "<C-d>") to command names, overriding or adding to the default
+	// key map. Mapping a key sequence to an empty string unbinds it.
+	Keys map[string]string `json:"keys"`
+
+	// Lists the columns displayed alongside entry names, in order. Valid
+	// columns are "size", "percent", "graph", "items", "modified", "used" (the
+	// newest modification or access time within an entry), "owner", "group",
+	// "permissions" and "usage" (disk usage). Left empty, the defaults are used.
+	Columns []string `json:"columns"`
+
+	// Names the built-in colour theme to use ("dark", "light" or "mono").
+	// Left empty, "mono" is used if NO_COLOR is set, and "dark" otherwise.
+	Theme string `json:"theme"`
+
+	// Overrides the theme's styles, keyed by style name (e.g. "directory")
+	// or extension pattern (e.g. "*.log"). Styles are described using
+	// colour and attribute names, such as "yellow bold on blue".
+	Colours map[string]string `json:"colours"`
+
+	// The size (in bytes) at which entries are styled as large.
+	// Left at zero, the default of 1GiB is used.
+	LargeSize int64 `json:"large_size"`
+
+	// The location of the deletion audit log. Left empty,
+	// the default location (see AuditLogPath) is used.
+	AuditLog string `json:"audit_log"`
+
+	// Watches for changes anywhere beneath the current directory, rather
+	// than only to its own entries, so that changed subtrees are re-sized.
+	WatchSubtree bool `json:"watch_subtree"`
+
+	// The number of files listed when showing the largest files below
+	// the current directory. Left at zero, the default of 100 is used.
+	LargestFileCount int `json:"largest_file_count"`
+
+	// Lists gitignore-style patterns for entries that are excluded from scans
+	// (e.g. ".git/"), in addition to any passed on the command line.
+	Exclude []string `json:"exclude"`
+
+	// Leaves hidden entries (dotfiles) out of listings at startup, although
+	// they're still counted towards directory sizes.
+	HideHidden bool `json:"hide_hidden"`
+}
+
+// Returns the location of the configuration file.
+func Path() string {
+	directory := os.Getenv("XDG_CONFIG_HOME")
+	if directory == "" {
+		directory = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+
+	return filepath.Join(directory, "purge", "config.json")
+}
+
+// Returns the location of the deletion audit log: the configured
+// location, if there is one, or $XDG_DATA_HOME/purge/audit.log,
+// falling back to ~/.local/share/purge/audit.log.
+func (config *Config) AuditLogPath() string {
+	if config.AuditLog != "" {
+		return config.AuditLog
+	}
+
+	directory := os.Getenv("XDG_DATA_HOME")
+	if directory == "" {
+		directory = filepath.Join(os.Getenv("HOME"), ".local", "share")
+	}
+
+	return filepath.Join(directory, "purge", "audit.log")
+}
+
+// Reads and parses the configuration file at the specified path.
+// If the file doesn't exist, an empty configuration is returned.
+func Load(path string) (*Config, error) {
+	config := new(Config)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
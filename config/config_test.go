@@ -0,0 +1,192 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Suite")
+}
+
+var _ = Describe("Config", func() {
+	Describe("Path", func() {
+		var originalValue string
+
+		BeforeEach(func() {
+			originalValue = os.Getenv("XDG_CONFIG_HOME")
+		})
+
+		AfterEach(func() {
+			os.Setenv("XDG_CONFIG_HOME", originalValue)
+		})
+
+		Context("XDG_CONFIG_HOME is set", func() {
+			It("returns a path within it", func() {
+				os.Setenv("XDG_CONFIG_HOME", "/config")
+				Expect(Path()).To(Equal("/config/purge/config.json"))
+			})
+		})
+
+		Context("XDG_CONFIG_HOME is not set", func() {
+			It("returns a path within the home directory", func() {
+				os.Setenv("XDG_CONFIG_HOME", "")
+				Expect(Path()).To(Equal(os.Getenv("HOME") + "/.config/purge/config.json"))
+			})
+		})
+	})
+
+	Describe("AuditLogPath", func() {
+		var originalValue string
+
+		BeforeEach(func() {
+			originalValue = os.Getenv("XDG_DATA_HOME")
+		})
+
+		AfterEach(func() {
+			os.Setenv("XDG_DATA_HOME", originalValue)
+		})
+
+		It("returns the configured location", func() {
+			config := &Config{AuditLog: "/var/log/purge.log"}
+			Expect(config.AuditLogPath()).To(Equal("/var/log/purge.log"))
+		})
+
+		It("defaults to a path within XDG_DATA_HOME", func() {
+			os.Setenv("XDG_DATA_HOME", "/data")
+			Expect(new(Config).AuditLogPath()).To(Equal("/data/purge/audit.log"))
+		})
+
+		It("falls back to a path within the home directory", func() {
+			os.Setenv("XDG_DATA_HOME", "")
+			Expect(new(Config).AuditLogPath()).To(Equal(os.Getenv("HOME") + "/.local/share/purge/audit.log"))
+		})
+	})
+
+	Describe("Load", func() {
+		var path string
+		var config *Config
+		var err error
+
+		JustBeforeEach(func() {
+			config, err = Load(path)
+		})
+
+		AfterEach(func() {
+			os.Remove(path)
+		})
+
+		Context("file does not exist", func() {
+			BeforeEach(func() {
+				path = "missing.json"
+			})
+
+			It("returns an empty configuration", func() {
+				Expect(err).To(BeNil())
+				Expect(config.Keys).To(BeEmpty())
+			})
+		})
+
+		Context("file contains key mappings", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"keys": {"<C-n>": "SelectNextEntry", "x": ""}}`), 0600)
+			})
+
+			It("parses the key mappings", func() {
+				Expect(err).To(BeNil())
+				Expect(config.Keys).To(Equal(map[string]string{"<C-n>": "SelectNextEntry", "x": ""}))
+			})
+		})
+
+		Context("file contains columns", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"columns": ["size", "owner"]}`), 0600)
+			})
+
+			It("parses the columns", func() {
+				Expect(err).To(BeNil())
+				Expect(config.Columns).To(Equal([]string{"size", "owner"}))
+			})
+		})
+
+		Context("file contains a theme and colours", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"theme": "light", "colours": {"*.log": "red"}, "large_size": 1024}`), 0600)
+			})
+
+			It("parses the theme, colours and large size", func() {
+				Expect(err).To(BeNil())
+				Expect(config.Theme).To(Equal("light"))
+				Expect(config.Colours).To(Equal(map[string]string{"*.log": "red"}))
+				Expect(config.LargeSize).To(Equal(int64(1024)))
+			})
+		})
+
+		Context("file enables subtree watching", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"watch_subtree": true}`), 0600)
+			})
+
+			It("parses the setting", func() {
+				Expect(err).To(BeNil())
+				Expect(config.WatchSubtree).To(BeTrue())
+			})
+		})
+
+		Context("file contains a largest file count", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"largest_file_count": 20}`), 0600)
+			})
+
+			It("parses the count", func() {
+				Expect(err).To(BeNil())
+				Expect(config.LargestFileCount).To(Equal(20))
+			})
+		})
+
+		Context("file contains exclude patterns", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"exclude": [".git/", "*.snapshot"]}`), 0600)
+			})
+
+			It("parses the patterns", func() {
+				Expect(err).To(BeNil())
+				Expect(config.Exclude).To(Equal([]string{".git/", "*.snapshot"}))
+			})
+		})
+
+		Context("file hides hidden entries", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"hide_hidden": true}`), 0600)
+			})
+
+			It("parses the setting", func() {
+				Expect(err).To(BeNil())
+				Expect(config.HideHidden).To(BeTrue())
+			})
+		})
+
+		Context("file is not valid JSON", func() {
+			BeforeEach(func() {
+				path = "config.json"
+				ioutil.WriteFile(path, []byte(`{"keys": `), 0600)
+			})
+
+			It("returns an error", func() {
+				Expect(err).ToNot(BeNil())
+			})
+		})
+	})
+})
@@ -0,0 +1,16 @@
+package directory
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// Returns the time the file described by info was last accessed,
+// or its modification time if the access time isn't available.
+func AccessTime(info os.FileInfo) time.Time {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package directory
+
+import (
+	"os"
+	"time"
+)
+
+// Returns the time the file described by info was last accessed. Access
+// times aren't available on this platform, so the modification time is used.
+func AccessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -7,71 +7,390 @@ Directory paths are always returned without a trailing slash.
 package directory
 
 import (
-	"io/ioutil"
 	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
-// Structure representing a directory entry.
+// Structure representing a directory entry. DiskUsage is the space
+// allocated to the entry on disk, which may differ from its (apparent) size.
+// LastModified and LastAccessed are the newest modification and access times
+// found within the entry; for directories, these cover their entire subtree
+// once their size has been calculated, as do Types, Owners and Groups.
+// Excluded is set if the entry matches the patterns set using SetExclude
+// (which callers check for, since the patterns apply to entries' paths).
+// Err is set if the entry (or its symlink target) couldn't be read. While a
+// directory's size is being calculated, Provisional is set once a partial
+// size (a lower bound on its final size) is known.
 type Entry struct {
 	Name           string
 	Size           int64
+	Items          int64
+	DiskUsage      int64
+	ModTime        time.Time
+	LastModified   time.Time
+	LastAccessed   time.Time
+	Types          TypeTotals
+	Owners         IDTotals
+	Groups         IDTotals
+	Mode           os.FileMode
+	Uid            uint32
+	Gid            uint32
 	IsDirectory    bool
+	IsSymlink      bool
+	Excluded       bool
 	SizeCalculated bool
+	Provisional    bool
+	Err            error
 }
 
+// Structure used to deliver the results of a size calculation.
+// Entry identifies the entry the results belong to, and remains
+// valid even if the entries have been sorted in the meantime.
+// Provisional results are partial totals, delivered while the
+// calculation is still underway.
 type EntrySize struct {
-	Index int
-	Size  int64
+	Entry        *Entry
+	Size         int64
+	Items        int64
+	DiskUsage    int64
+	LastModified time.Time
+	LastAccessed time.Time
+	Types        TypeTotals
+	Owners       IDTotals
+	Groups       IDTotals
+	Provisional  bool
 }
 
-// Alias a slice of entries so that
-// we can implement sort.Interface.
-type SortableEntries []*Entry
+// The minimum time between the provisional results sent by Size.
+var ProvisionalInterval = 200 * time.Millisecond
+
+// Builds an entry using the details in info. Directory sizes
+// aren't calculated here, and are left for Size to fill in.
+func NewEntry(info os.FileInfo) *Entry {
+	entry := &Entry{Name: info.Name(), ModTime: info.ModTime(), LastModified: info.ModTime(),
+		Mode: info.Mode(), IsDirectory: info.IsDir(), SizeCalculated: !info.IsDir()}
+
+	if !entry.IsDirectory {
+		entry.Size = info.Size()
+		entry.DiskUsage = DiskUsage(info)
+		entry.LastAccessed = AccessTime(info)
+	}
+	entry.Uid, entry.Gid = Ownership(info)
+
+	return entry
+}
+
+// Returns the time the entry was last used: the newest
+// modification or access time found within it.
+func (entry *Entry) LastUsed() time.Time {
+	if entry.LastAccessed.After(entry.LastModified) {
+		return entry.LastAccessed
+	}
+	return entry.LastModified
+}
+
+// Returns the space allocated on disk for the file described by info.
+func DiskUsage(info os.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Blocks) * 512
+	}
+	return info.Size()
+}
+
+var ownerNames = make(map[uint32]string)
+var groupNames = make(map[uint32]string)
+var ownerNamesMutex sync.Mutex
+
+// Returns the name of the user with the specified ID,
+// or the ID itself if the user can't be found.
+func OwnerName(uid uint32) string {
+	ownerNamesMutex.Lock()
+	defer ownerNamesMutex.Unlock()
+
+	// Cache names, since looking them up can be slow.
+	if name, ok := ownerNames[uid]; ok {
+		return name
+	}
+
+	id := strconv.FormatUint(uint64(uid), 10)
+	name := id
+	if owner, err := user.LookupId(id); err == nil {
+		name = owner.Username
+	}
+	ownerNames[uid] = name
+
+	return name
+}
+
+// Returns the name of the group with the specified ID,
+// or the ID itself if the group can't be found.
+func GroupName(gid uint32) string {
+	ownerNamesMutex.Lock()
+	defer ownerNamesMutex.Unlock()
+
+	if name, ok := groupNames[gid]; ok {
+		return name
+	}
+
+	id := strconv.FormatUint(uint64(gid), 10)
+	name := id
+	if group, err := user.LookupGroupId(id); err == nil {
+		name = group.Name
+	}
+	groupNames[gid] = name
+
+	return name
+}
+
+// Modes by which entries can be sorted.
+type SortMode int
+
+const (
+	SortByName SortMode = iota
+	SortBySize
+	SortByModTime
+	SortByItems
+	SortByExtension
+	SortByLastUsed
+)
+
+// Returns the sort mode's name, for display purposes.
+func (mode SortMode) String() string {
+	switch mode {
+	case SortBySize:
+		return "size"
+	case SortByModTime:
+		return "modified"
+	case SortByItems:
+		return "items"
+	case SortByExtension:
+		return "extension"
+	case SortByLastUsed:
+		return "used"
+	}
+	return "name"
+}
+
+// Returns the sort mode that follows this one, wrapping around after the last.
+func (mode SortMode) Next() SortMode {
+	return (mode + 1) % (SortByLastUsed + 1)
+}
+
+// Reports whether the mode orders entries by something that changes as
+// directory sizes are calculated, requiring entries to be sorted again.
+func (mode SortMode) DependsOnSize() bool {
+	return mode == SortBySize || mode == SortByItems || mode == SortByLastUsed
+}
+
+// Structure describing how entries should be ordered. Names and extensions
+// are sorted alphabetically, while sizes, item counts, modification times and
+// the times entries were last used are sorted with the largest/newest first;
+// Reverse flips this.
+type SortOrder struct {
+	Mode             SortMode
+	Reverse          bool
+	DirectoriesFirst bool
+}
+
+// Reports whether entries are sorted in descending order
+// (e.g. Z to A, or largest to smallest).
+func (order SortOrder) Descending() bool {
+	switch order.Mode {
+	case SortBySize, SortByModTime, SortByItems, SortByLastUsed:
+		return !order.Reverse
+	}
+	return order.Reverse
+}
+
+// Sorts the entries in place using the sort order.
+func (order SortOrder) Sort(entries []*Entry) {
+	sort.Sort(sortableEntries{entries, order})
+}
+
+// Pairs a slice of entries with a sort
+// order so that we can implement sort.Interface.
+type sortableEntries struct {
+	entries []*Entry
+	order   SortOrder
+}
 
 // Implement sort.Interface length function.
-func (e SortableEntries) Len() int {
-	return len(e)
+func (e sortableEntries) Len() int {
+	return len(e.entries)
 }
 
-// Implement sort.Interface comparison function,
-// using the entry size as a comparator.
-func (e SortableEntries) Less(i, j int) bool {
-	if e[i].Size > e[j].Size {
-		return true
-	} else {
-		return false
+// Implement sort.Interface comparison function, using the sort order's mode
+// as the comparator and falling back to names for otherwise equal entries.
+func (e sortableEntries) Less(i, j int) bool {
+	a, b := e.entries[i], e.entries[j]
+
+	// Grouping directories isn't affected by reversing the order.
+	if e.order.DirectoriesFirst && a.IsDirectory != b.IsDirectory {
+		return a.IsDirectory
+	}
+
+	var comparison int
+	switch e.order.Mode {
+	case SortBySize:
+		comparison = compareInts(b.Size, a.Size)
+	case SortByModTime:
+		comparison = compareInts(b.ModTime.UnixNano(), a.ModTime.UnixNano())
+	case SortByItems:
+		comparison = compareInts(b.Items, a.Items)
+	case SortByLastUsed:
+		comparison = compareInts(b.LastUsed().UnixNano(), a.LastUsed().UnixNano())
+	case SortByExtension:
+		comparison = strings.Compare(Extension(a.Name), Extension(b.Name))
+	}
+	if comparison == 0 {
+		comparison = strings.Compare(a.Name, b.Name)
 	}
+
+	if e.order.Reverse {
+		return comparison > 0
+	}
+	return comparison < 0
 }
 
 // Implement sort.Interface swap method,
 // used to re-arrange misplaced entries.
-func (e SortableEntries) Swap(i, j int) {
-	e[i], e[j] = e[j], e[i]
-}
-
-// Calculates and returns the size (in
-// bytes) of the directory for the given path.
-func Size(path string, index int, entrySizeChannel chan *EntrySize) {
-	var size int64
-
-	// Read the directory entries.
-	entries, _ := ioutil.ReadDir(path)
-
-	// Sum the entry sizes, recursing if necessary.
-	for _, entry := range entries {
-		if os.FileMode.IsDir(entry.Mode()) {
-			// Allocate a channel to receive the size.
-			recursiveResult := make(chan *EntrySize)
-
-			// Recurse with a useless index (we're only summarizing, we don't care about order),
-			// blocking until we receive an answer (recursive calls don't need to be async).
-			go Size(path+"/"+entry.Name(), 0, recursiveResult)
-			size += (<-recursiveResult).Size
-		} else {
-			size += entry.Size()
+func (e sortableEntries) Swap(i, j int) {
+	e.entries[i], e.entries[j] = e.entries[j], e.entries[i]
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Calculates and returns the size (in bytes) of the directory for the given
+// path, along with the number of items it contains, its disk usage, the
+// newest modification and access times found within it and the totals of
+// the files within it by type, owner and group. The entry
+// is passed back with the results so that the caller can identify what they belong to.
+// Progress is reported to the scan as the directory is walked, unless it's nil.
+// Entries excluded using SetExclude aren't counted.
+//
+// If there's an entry, provisional results (partial totals) are also sent at
+// most once every ProvisionalInterval while the calculation is underway. They're
+// dropped, rather than waiting, if the channel isn't ready to receive them.
+//
+// Closing done abandons the calculation, which then stops early without
+// sending anything more (a nil channel is never closed).
+func Size(path string, entry *Entry, entrySizeChannel chan *EntrySize, done <-chan struct{}, scan *Scan) {
+	total := &EntrySize{Entry: entry, Types: make(TypeTotals),
+		Owners: make(IDTotals), Groups: make(IDTotals)}
+
+	var lastReport time.Time
+	report := func() {
+		if entry == nil || time.Since(lastReport) < ProvisionalInterval {
+			return
 		}
+		lastReport = time.Now()
+
+		// The totals by type and owner are still being added to, so they're left out.
+		partial := *total
+		partial.Types, partial.Owners, partial.Groups = nil, nil, nil
+		partial.Provisional = true
+		select {
+		case entrySizeChannel <- &partial:
+		default:
+		}
+	}
+	if !walk(path, total, scan, report, done) {
+		return
 	}
 
 	// Send the entry size on to the return channel.
-	entrySizeChannel <- &EntrySize{Index: index, Size: size}
+	select {
+	case entrySizeChannel <- total:
+	case <-done:
+	}
+}
+
+// Adds the size, items, disk usage, file types and owners of the directory at the
+// given path to the total (noting the newest modification and access times), recursing
+// into subdirectories, and calling report after each directory's files have
+// been counted. Directories' access times are left out, since they're
+// updated by reading them (i.e. by walking them). Returns false, without
+// finishing the walk, if done is closed.
+func walk(path string, total *EntrySize, scan *Scan, report func(), done <-chan struct{}) bool {
+	if abandoned(done) {
+		return false
+	}
+
+	// Count the space used by the directory itself.
+	if info, err := os.Lstat(path); err == nil {
+		total.DiskUsage += DiskUsage(info)
+		total.noteModified(info.ModTime())
+	}
+
+	// Read the directory entries, leaving out any that are excluded.
+	entries := readDir(path)
+
+	// Count the files first, so that they're reflected in progress
+	// and provisional results before descending into subdirectories.
+	var files, fileSize int64
+	for _, fileInfo := range entries {
+		total.Items++
+
+		if !fileInfo.IsDir() {
+			files++
+			fileSize += fileInfo.Size()
+			total.DiskUsage += DiskUsage(fileInfo)
+			total.noteModified(fileInfo.ModTime())
+			total.noteAccessed(AccessTime(fileInfo))
+			total.Types.Add(Extension(fileInfo.Name()), fileInfo.Size(), 1)
+			uid, gid := Ownership(fileInfo)
+			total.Owners.Add(uid, fileInfo.Size(), 1)
+			total.Groups.Add(gid, fileInfo.Size(), 1)
+		}
+	}
+	total.Size += fileSize
+	scan.visit(path, files, fileSize)
+	report()
+
+	for _, fileInfo := range entries {
+		if fileInfo.IsDir() && !walk(path+"/"+fileInfo.Name(), total, scan, report, done) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reports whether done has been closed, abandoning the work
+// it was passed along with (a nil channel is never closed).
+func abandoned(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Records the modification time, if it's the newest seen so far.
+func (total *EntrySize) noteModified(modTime time.Time) {
+	if modTime.After(total.LastModified) {
+		total.LastModified = modTime
+	}
+}
+
+// Records the access time, if it's the newest seen so far.
+func (total *EntrySize) noteAccessed(accessTime time.Time) {
+	if accessTime.After(total.LastAccessed) {
+		total.LastAccessed = accessTime
+	}
 }
@@ -1,8 +1,10 @@
 package directory
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,15 +18,24 @@ func TestDirectory(t *testing.T) {
 var _ = Describe("Directory", func() {
 	Describe("Size", func() {
 		var result chan *EntrySize
-		var index int
+		var entry *Entry
 
-		Context("when passed a directory path and an index", func() {
+		// Receives results until the final (non-provisional) ones arrive.
+		final := func() *EntrySize {
+			entrySize := <-result
+			for entrySize.Provisional {
+				entrySize = <-result
+			}
+			return entrySize
+		}
+
+		Context("when passed a directory path and an entry", func() {
 			BeforeEach(func() {
 				result = make(chan *EntrySize)
 				dir, _ := os.Getwd()
-				index = 4
+				entry = &Entry{Name: "sample", IsDirectory: true}
 
-				go Size(dir+"/navigator/sample", index, result)
+				go Size(dir+"/navigator/sample", entry, result, nil, nil)
 			})
 
 			It("calculates the size of the directory", func(done Done) {
@@ -32,17 +43,706 @@ var _ = Describe("Directory", func() {
 				// of the sample directory's contents.
 				const expectedSize int64 = 512026
 
-				entrySize := <-result
+				entrySize := final()
 				Expect(entrySize.Size).To(Equal(expectedSize))
 				close(done)
 			})
 
-			It("returns the index", func(done Done) {
+			It("returns the entry", func(done Done) {
+				entrySize := final()
+				Expect(entrySize.Entry).To(BeIdenticalTo(entry))
+				close(done)
+			})
+
+			It("counts the items in the directory", func(done Done) {
+				entrySize := final()
+				Expect(entrySize.Items).To(BeEquivalentTo(5))
+				close(done)
+			})
+
+			It("calculates the disk usage of the directory", func(done Done) {
+				entrySize := final()
+				Expect(entrySize.DiskUsage).To(BeNumerically(">", 0))
+				close(done)
+			})
+
+			It("totals the files in the directory by type", func(done Done) {
+				entrySize := final()
+				Expect(entrySize.Types).To(HaveLen(1))
+				Expect(*entrySize.Types[""]).To(Equal(FileTotal{Size: 512026, Files: 4}))
+				close(done)
+			})
+
+			It("totals the files in the directory by owner and group", func(done Done) {
+				entrySize := final()
+				var files int64
+				for _, total := range entrySize.Owners {
+					files += total.Files
+				}
+				Expect(files).To(BeEquivalentTo(4))
+				Expect(entrySize.Groups).ToNot(BeEmpty())
+				close(done)
+			})
+		})
+
+		Context("when passed a directory with files of different ages", func() {
+			var path string
+			old := time.Now().Add(-400 * 24 * time.Hour).Truncate(time.Second)
+			accessed := time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Second)
+			modified := time.Now().Add(-60 * 24 * time.Hour).Truncate(time.Second)
+
+			BeforeEach(func() {
+				path, _ = ioutil.TempDir("", "purge")
+				os.Mkdir(path+"/nested", 0755)
+				ioutil.WriteFile(path+"/nested/accessed", []byte("accessed"), 0644)
+				ioutil.WriteFile(path+"/modified", []byte("modified"), 0644)
+				os.Chtimes(path+"/nested/accessed", accessed, old)
+				os.Chtimes(path+"/modified", old, modified)
+				os.Chtimes(path+"/nested", old, old)
+				os.Chtimes(path, old, old)
+
+				result = make(chan *EntrySize)
+				go Size(path, nil, result, nil, nil)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(path)
+			})
+
+			It("records the newest modification time within it", func(done Done) {
+				Expect(final().LastModified).To(BeTemporally("==", modified))
+				close(done)
+			})
+
+			It("records the newest access time of the files within it", func(done Done) {
+				Expect(final().LastAccessed).To(BeTemporally("==", accessed))
+				close(done)
+			})
+		})
+
+		Context("when partial totals are known", func() {
+			var originalInterval time.Duration
+
+			BeforeEach(func() {
+				originalInterval = ProvisionalInterval
+				ProvisionalInterval = 0
+
+				// Provisional results are dropped if they can't be sent right away.
+				result = make(chan *EntrySize, 10)
+				dir, _ := os.Getwd()
+				entry = &Entry{Name: "sample", IsDirectory: true}
+
+				go Size(dir+"/navigator/sample", entry, result, nil, nil)
+			})
+
+			AfterEach(func() {
+				ProvisionalInterval = originalInterval
+			})
+
+			It("sends them as provisional results before the final ones", func(done Done) {
 				entrySize := <-result
-				Expect(entrySize.Index).To(Equal(index))
+				Expect(entrySize.Provisional).To(BeTrue())
+				Expect(entrySize.Entry).To(BeIdenticalTo(entry))
+				Expect(entrySize.Size).To(BeNumerically("<=", 512026))
+
+				entrySize = final()
+				Expect(entrySize.Size).To(BeEquivalentTo(512026))
+				close(done)
+			})
+		})
+
+		Context("when passed a scan", func() {
+			var scan *Scan
+			var dir string
+
+			BeforeEach(func() {
+				result = make(chan *EntrySize)
+				dir, _ = os.Getwd()
+				scan = NewScan()
+
+				go Size(dir+"/navigator/sample", nil, result, nil, scan)
+			})
+
+			It("reports the files and bytes visited", func(done Done) {
+				<-result
+				Expect(scan.Files()).To(BeEquivalentTo(4))
+				Expect(scan.Bytes()).To(BeEquivalentTo(512026))
+				Expect(scan.Throughput()).To(BeNumerically(">", 0))
+				close(done)
+			})
+
+			It("reports the directory most recently entered", func(done Done) {
+				<-result
+				Expect(scan.Current()).To(Equal(dir + "/navigator/sample/directory"))
+				close(done)
+			})
+		})
+
+		Context("when abandoned", func() {
+			It("returns without sending its results", func(done Done) {
+				dir, _ := os.Getwd()
+				abandon := make(chan struct{})
+				close(abandon)
+
+				// Nothing receives from the channel, so sending would block forever.
+				Size(dir+"/navigator/sample", nil, make(chan *EntrySize), abandon, nil)
+				close(done)
+			})
+		})
+	})
+
+	Describe("LargestFiles", func() {
+		var path string
+
+		names := func(entries []*Entry) []string {
+			result := make([]string, len(entries))
+			for index, entry := range entries {
+				result[index] = entry.Name
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			dir, _ := os.Getwd()
+			path = dir + "/navigator/sample"
+		})
+
+		It("finds the largest files at any depth, named relative to the directory", func() {
+			entries := LargestFiles(path, 2, nil)
+			Expect(names(entries)).To(ConsistOf("file", "directory/file"))
+			Expect(entries[0].Size).To(BeEquivalentTo(256010))
+		})
+
+		It("lists the largest files first", func() {
+			entries := LargestFiles(path, 3, nil)
+			Expect(names(entries)[2]).To(Equal("small_file"))
+		})
+
+		It("leaves out directories", func() {
+			entries := LargestFiles(path, 10, nil)
+			Expect(names(entries)).To(ConsistOf("file", "directory/file", "small_file", "empty_file"))
+		})
+
+		It("only includes matching files, if given a match", func() {
+			entries := LargestFilesMatching(path, 10, func(info os.FileInfo) bool {
+				return info.Name() == "small_file"
+			}, nil, nil)
+			Expect(names(entries)).To(Equal([]string{"small_file"}))
+		})
+
+		It("stops searching once abandoned", func() {
+			abandon := make(chan struct{})
+			close(abandon)
+			Expect(LargestFilesMatching(path, 10, nil, abandon, nil)).To(BeNil())
+		})
+
+		It("reports progress to the scan", func() {
+			scan := NewScan()
+			LargestFiles(path, 1, scan)
+			Expect(scan.Bytes()).To(BeEquivalentTo(512026))
+		})
+	})
+
+	Describe("ExcludeList", func() {
+		var list *ExcludeList
+		var err error
+
+		excludes := func(patterns ...string) {
+			list, err = NewExcludeList(patterns)
+			Expect(err).To(BeNil())
+		}
+
+		It("matches names at any depth", func() {
+			excludes("*.snapshot", ".git")
+			Expect(list.Excludes("/home/daily.snapshot", true)).To(BeTrue())
+			Expect(list.Excludes("/home/project/.git", true)).To(BeTrue())
+			Expect(list.Excludes("/home/project/.gitignore", false)).To(BeFalse())
+			Expect(list.Excludes("/home/snapshot", true)).To(BeFalse())
+		})
+
+		It("only matches directories with patterns ending in a slash", func() {
+			excludes("cache/")
+			Expect(list.Excludes("/home/cache", true)).To(BeTrue())
+			Expect(list.Excludes("/home/cache", false)).To(BeFalse())
+		})
+
+		It("matches absolute paths with patterns starting with a slash", func() {
+			excludes("/home/*/backups")
+			Expect(list.Excludes("/home/alice/backups", true)).To(BeTrue())
+			Expect(list.Excludes("/srv/home/alice/backups", true)).To(BeFalse())
+			Expect(list.Excludes("/home/alice/old/backups", true)).To(BeFalse())
+		})
+
+		It("resolves relative paths before matching patterns starting with a slash", func() {
+			dir, _ := os.Getwd()
+			excludes(dir + "/navigator/sample")
+			Expect(list.Excludes("navigator/sample", true)).To(BeTrue())
+
+			info, _ := os.Lstat("navigator/sample")
+			SetExclude(list)
+			defer SetExclude(nil)
+			Expect(Excluded("navigator", info)).To(BeTrue())
+			Expect(readDir("navigator")).ToNot(ContainElement(WithTransform(os.FileInfo.Name, Equal("sample"))))
+		})
+
+		It("matches the end of paths with patterns containing a slash", func() {
+			excludes(".git/objects")
+			Expect(list.Excludes("/home/project/.git/objects", true)).To(BeTrue())
+			Expect(list.Excludes("/home/project/objects", true)).To(BeFalse())
+		})
+
+		It("matches across directories with double asterisks", func() {
+			excludes("/home/**/node_modules")
+			Expect(list.Excludes("/home/node_modules", true)).To(BeTrue())
+			Expect(list.Excludes("/home/a/b/node_modules", true)).To(BeTrue())
+		})
+
+		It("matches sets of characters", func() {
+			excludes("core.[0-9]*", "[!a]*.tmp")
+			Expect(list.Excludes("/tmp/core.1234", false)).To(BeTrue())
+			Expect(list.Excludes("/tmp/core.dump", false)).To(BeFalse())
+			Expect(list.Excludes("/tmp/b.tmp", false)).To(BeTrue())
+			Expect(list.Excludes("/tmp/a.tmp", false)).To(BeFalse())
+		})
+
+		It("re-includes entries matching negated patterns", func() {
+			excludes("*.log", "!important.log")
+			Expect(list.Excludes("/var/debug.log", false)).To(BeTrue())
+			Expect(list.Excludes("/var/important.log", false)).To(BeFalse())
+		})
+
+		It("skips blank lines and comments", func() {
+			excludes("", "# *.log")
+			Expect(list.Excludes("/var/debug.log", false)).To(BeFalse())
+		})
+
+		It("rejects empty patterns", func() {
+			_, err = NewExcludeList([]string{"!"})
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("doesn't exclude anything when nil", func() {
+			Expect(list.Excludes("/anything", true)).To(BeFalse())
+		})
+
+		Context("when set for scans", func() {
+			var path string
+
+			BeforeEach(func() {
+				path, _ = ioutil.TempDir("", "purge")
+				os.MkdirAll(path+"/.git/objects", 0755)
+				ioutil.WriteFile(path+"/.git/objects/pack", []byte("packed objects"), 0644)
+				ioutil.WriteFile(path+"/file", []byte("file"), 0644)
+
+				excludes(".git/")
+				SetExclude(list)
+			})
+
+			AfterEach(func() {
+				SetExclude(nil)
+				os.RemoveAll(path)
+			})
+
+			It("leaves excluded entries out of sizes", func() {
+				result := make(chan *EntrySize, 1)
+				Size(path, nil, result, nil, nil)
+				entrySize := <-result
+				Expect(entrySize.Size).To(BeEquivalentTo(4))
+				Expect(entrySize.Items).To(BeEquivalentTo(1))
+			})
+
+			It("leaves excluded entries out of the largest files", func() {
+				Expect(LargestFiles(path, 10, nil)).To(HaveLen(1))
+			})
+
+			It("reports whether entries are excluded", func() {
+				info, _ := os.Lstat(path + "/.git")
+				Expect(Excluded(path, info)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("IDTotals", func() {
+		It("adds to the total for an ID, dropping it once it has no files", func() {
+			totals := make(IDTotals)
+			totals.Add(1000, 10, 2)
+			Expect(*totals[1000]).To(Equal(FileTotal{Size: 10, Files: 2}))
+			totals.Add(1000, -10, -2)
+			Expect(totals).To(BeEmpty())
+		})
+
+		It("describes a file by its own owner and group", func() {
+			entry := &Entry{Name: "file", Size: 42, Uid: 1000, Gid: 100}
+			Expect(*entry.OwnerTotals()[1000]).To(Equal(FileTotal{Size: 42, Files: 1}))
+			Expect(*entry.GroupTotals()[100]).To(Equal(FileTotal{Size: 42, Files: 1}))
+		})
+	})
+
+	Describe("GroupName", func() {
+		It("falls back to the group's ID", func() {
+			Expect(GroupName(987654321)).To(Equal("987654321"))
+		})
+	})
+
+	Describe("TypeTotals", func() {
+		It("adds to the total for an extension", func() {
+			totals := make(TypeTotals)
+			totals.Add(".log", 10, 1)
+			totals.Add(".log", 5, 1)
+			Expect(*totals[".log"]).To(Equal(FileTotal{Size: 15, Files: 2}))
+		})
+
+		It("drops totals that no longer include any files", func() {
+			totals := make(TypeTotals)
+			totals.Add(".log", 10, 1)
+			totals.Add(".log", -10, -1)
+			Expect(totals).To(BeEmpty())
+		})
+
+		It("describes a file by its own size", func() {
+			entry := &Entry{Name: "Movie.MP4", Size: 42}
+			Expect(*entry.TypeTotals()[".mp4"]).To(Equal(FileTotal{Size: 42, Files: 1}))
+		})
+	})
+
+	Describe("Category", func() {
+		It("categorizes known extensions", func() {
+			Expect(Category(".mp4")).To(Equal("video"))
+			Expect(Category(".o")).To(Equal("objects"))
+		})
+
+		It("categorizes unknown extensions as other", func() {
+			Expect(Category(".unknown")).To(Equal(OtherCategory))
+			Expect(Category("")).To(Equal(OtherCategory))
+		})
+	})
+
+	Describe("FindDuplicates", func() {
+		var path string
+		var groups []*DuplicateGroup
+
+		names := func(group *DuplicateGroup) []string {
+			result := make([]string, len(group.Entries))
+			for index, entry := range group.Entries {
+				result[index] = entry.Name
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			path, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(path+"/directory", 0755)
+
+			// Files that only differ after the part that's hashed first.
+			large := make([]byte, 5000)
+			ioutil.WriteFile(path+"/large", large, 0644)
+			ioutil.WriteFile(path+"/directory/large", large, 0644)
+			large[4999] = 1
+			ioutil.WriteFile(path+"/different", large, 0644)
+			os.Link(path+"/large", path+"/link")
+
+			ioutil.WriteFile(path+"/small", []byte("data"), 0644)
+			ioutil.WriteFile(path+"/directory/small", []byte("data"), 0644)
+			ioutil.WriteFile(path+"/empty", nil, 0644)
+			ioutil.WriteFile(path+"/directory/empty", nil, 0644)
+
+			groups = FindDuplicates(path, nil, nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(path)
+		})
+
+		It("groups files with identical contents, wasting the most space first", func() {
+			Expect(groups).To(HaveLen(2))
+			Expect(names(groups[0])).To(Equal([]string{"directory/large", "large"}))
+			Expect(names(groups[1])).To(Equal([]string{"directory/small", "small"}))
+		})
+
+		It("reports the space wasted by each group", func() {
+			Expect(groups[0].Size).To(BeEquivalentTo(5000))
+			Expect(groups[0].Wasted()).To(BeEquivalentTo(5000))
+			Expect(groups[1].Wasted()).To(BeEquivalentTo(4))
+		})
+
+		It("stops searching once abandoned", func() {
+			abandon := make(chan struct{})
+			close(abandon)
+			Expect(FindDuplicates(path, abandon, nil)).To(BeNil())
+		})
+
+		It("reports the bytes hashed to the scan", func() {
+			scan := NewScan()
+			FindDuplicates(path, nil, scan)
+			Expect(scan.Hashed()).To(BeNumerically(">", 0))
+		})
+	})
+
+	Describe("SameContents", func() {
+		var path string
+
+		BeforeEach(func() {
+			path, _ = ioutil.TempDir("", "purge")
+			ioutil.WriteFile(path+"/a", []byte("data"), 0644)
+			ioutil.WriteFile(path+"/b", []byte("data"), 0644)
+			ioutil.WriteFile(path+"/c", []byte("date"), 0644)
+			ioutil.WriteFile(path+"/d", []byte("data and more"), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(path)
+		})
+
+		It("reports whether files have identical contents", func() {
+			Expect(SameContents(path+"/a", path+"/b")).To(BeTrue())
+			Expect(SameContents(path+"/a", path+"/c")).To(BeFalse())
+			Expect(SameContents(path+"/a", path+"/d")).To(BeFalse())
+			Expect(SameContents(path+"/d", path+"/a")).To(BeFalse())
+		})
+
+		It("returns an error if a file can't be read", func() {
+			_, err := SameContents(path+"/a", path+"/missing")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("Watch", func() {
+		var path string
+		var watcher *Watcher
+
+		// Receives changes until the named entry is reported.
+		reported := func(name string) bool {
+			for change := range watcher.Changes {
+				if change == name {
+					return true
+				}
+			}
+			return false
+		}
+
+		BeforeEach(func() {
+			path, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(path+"/directory", 0755)
+		})
+
+		AfterEach(func() {
+			watcher.Close()
+			os.RemoveAll(path)
+		})
+
+		Context("when watching the directory's own entries", func() {
+			BeforeEach(func() {
+				watcher = Watch(path, false)
+			})
+
+			It("reports created entries", func(done Done) {
+				ioutil.WriteFile(path+"/file", []byte("data"), 0644)
+				Expect(reported("file")).To(BeTrue())
+				close(done)
+			})
+
+			It("reports removed entries", func(done Done) {
+				os.Remove(path + "/directory")
+				Expect(reported("directory")).To(BeTrue())
+				close(done)
+			})
+		})
+
+		Context("when watching the directory's subtree", func() {
+			BeforeEach(func() {
+				watcher = Watch(path, true)
+			})
+
+			It("reports changes within an entry using its name", func(done Done) {
+				ioutil.WriteFile(path+"/directory/file", []byte("data"), 0644)
+				Expect(reported("directory")).To(BeTrue())
 				close(done)
 			})
 		})
+
+		Context("when polling the directory", func() {
+			var originalInterval time.Duration
+
+			BeforeEach(func() {
+				originalInterval = PollInterval
+				PollInterval = 10 * time.Millisecond
+
+				watcher = newWatcher()
+				watcher.startPolling(path)
+			})
+
+			AfterEach(func() {
+				watcher.Close()
+				PollInterval = originalInterval
+			})
+
+			It("reports created entries", func(done Done) {
+				ioutil.WriteFile(path+"/file", []byte("data"), 0644)
+				Expect(reported("file")).To(BeTrue())
+				close(done)
+			})
+
+			It("reports removed entries", func(done Done) {
+				os.Remove(path + "/directory")
+				Expect(reported("directory")).To(BeTrue())
+				close(done)
+			})
+		})
+	})
+
+	Describe("NewEntry", func() {
+		var entry *Entry
+		var info os.FileInfo
+
+		JustBeforeEach(func() {
+			entry = NewEntry(info)
+		})
+
+		Context("when passed a file", func() {
+			BeforeEach(func() {
+				dir, _ := os.Getwd()
+				info, _ = os.Stat(dir + "/navigator/sample/file")
+			})
+
+			It("uses the file's details", func() {
+				Expect(entry.Name).To(Equal("file"))
+				Expect(entry.Size).To(Equal(info.Size()))
+				Expect(entry.Mode).To(Equal(info.Mode()))
+				Expect(entry.ModTime).To(Equal(info.ModTime()))
+				Expect(entry.Uid).To(BeEquivalentTo(os.Getuid()))
+			})
+
+			It("flags its size as calculated", func() {
+				Expect(entry.SizeCalculated).To(BeTrue())
+			})
+		})
+
+		Context("when passed a directory", func() {
+			BeforeEach(func() {
+				dir, _ := os.Getwd()
+				info, _ = os.Stat(dir + "/navigator/sample/directory")
+			})
+
+			It("leaves its size to be calculated", func() {
+				Expect(entry.IsDirectory).To(BeTrue())
+				Expect(entry.SizeCalculated).To(BeFalse())
+				Expect(entry.Size).To(BeZero())
+			})
+		})
+	})
+
+	Describe("SortOrder", func() {
+		var entries []*Entry
+		var order SortOrder
+
+		names := func() []string {
+			result := make([]string, len(entries))
+			for index, entry := range entries {
+				result[index] = entry.Name
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			now := time.Now()
+			entries = []*Entry{
+				&Entry{Name: "b.txt", Size: 10, Items: 0, ModTime: now.Add(-time.Hour)},
+				&Entry{Name: "c", Size: 30, Items: 4, ModTime: now.Add(-2 * time.Hour), IsDirectory: true},
+				&Entry{Name: "a.log", Size: 20, Items: 0, ModTime: now},
+				&Entry{Name: "d", Size: 5, Items: 9, ModTime: now.Add(-3 * time.Hour), IsDirectory: true},
+			}
+			order = SortOrder{}
+		})
+
+		JustBeforeEach(func() {
+			order.Sort(entries)
+		})
+
+		Context("sorting by name", func() {
+			It("sorts entries alphabetically", func() {
+				Expect(names()).To(Equal([]string{"a.log", "b.txt", "c", "d"}))
+			})
+		})
+
+		Context("sorting by size", func() {
+			BeforeEach(func() {
+				order.Mode = SortBySize
+			})
+
+			It("sorts the largest entries first", func() {
+				Expect(names()).To(Equal([]string{"c", "a.log", "b.txt", "d"}))
+			})
+		})
+
+		Context("sorting by modification time", func() {
+			BeforeEach(func() {
+				order.Mode = SortByModTime
+			})
+
+			It("sorts the newest entries first", func() {
+				Expect(names()).To(Equal([]string{"a.log", "b.txt", "c", "d"}))
+			})
+		})
+
+		Context("sorting by item count", func() {
+			BeforeEach(func() {
+				order.Mode = SortByItems
+			})
+
+			It("sorts entries with the most items first, then by name", func() {
+				Expect(names()).To(Equal([]string{"d", "c", "a.log", "b.txt"}))
+			})
+		})
+
+		Context("sorting by extension", func() {
+			BeforeEach(func() {
+				order.Mode = SortByExtension
+			})
+
+			It("sorts entries alphabetically by extension, then by name", func() {
+				Expect(names()).To(Equal([]string{"c", "d", "a.log", "b.txt"}))
+			})
+		})
+
+		Context("sorting by last use", func() {
+			BeforeEach(func() {
+				order.Mode = SortByLastUsed
+				now := time.Now()
+				entries[0].LastAccessed = now.Add(time.Hour)
+				entries[1].LastModified = now.Add(-time.Hour)
+				entries[2].LastModified = now
+				entries[3].LastModified = now.Add(-2 * time.Hour)
+				entries[3].LastAccessed = now.Add(-3 * time.Hour)
+			})
+
+			It("sorts the most recently used entries first", func() {
+				Expect(names()).To(Equal([]string{"b.txt", "a.log", "c", "d"}))
+			})
+		})
+
+		Context("sorting by size in reverse", func() {
+			BeforeEach(func() {
+				order = SortOrder{Mode: SortBySize, Reverse: true}
+			})
+
+			It("sorts the smallest entries first", func() {
+				Expect(names()).To(Equal([]string{"d", "b.txt", "a.log", "c"}))
+			})
+
+			It("is not descending", func() {
+				Expect(order.Descending()).To(BeFalse())
+			})
+		})
+
+		Context("sorting with directories first", func() {
+			BeforeEach(func() {
+				order = SortOrder{Mode: SortByName, Reverse: true, DirectoriesFirst: true}
+			})
+
+			It("lists directories before files", func() {
+				Expect(names()).To(Equal([]string{"d", "c", "b.txt", "a.log"}))
+			})
+		})
 	})
 })
 
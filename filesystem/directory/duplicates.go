@@ -0,0 +1,221 @@
+package directory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"syscall"
+)
+
+// The number of bytes hashed from the beginning of each file to rule out most
+// files of the same size quickly, before hashing them in their entirety.
+const partialHashSize = 4096
+
+// Returned when hashing a file is abandoned part of the way through.
+var errAbandoned = errors.New("abandoned")
+
+// Structure describing a set of files with identical contents.
+type DuplicateGroup struct {
+	Size    int64
+	Entries []*Entry
+}
+
+// Returns the space wasted by the group: the size of every copy but one.
+func (group *DuplicateGroup) Wasted() int64 {
+	if len(group.Entries) < 2 {
+		return 0
+	}
+	return group.Size * int64(len(group.Entries)-1)
+}
+
+// Finds the files within the directory at the given path (at any depth)
+// that have identical contents, grouping them together. Files are grouped
+// by size, then by a hash of their first few kilobytes and then by a hash of
+// their entire contents. Empty files, and extra hard links to the same file,
+// aren't included. The entries are named using their paths, relative to the
+// directory, and the groups are returned in order of the space they waste.
+// Progress is reported to the scan as the directory is walked and files are
+// hashed, unless it's nil. Entries excluded using SetExclude are left out.
+// Closing done abandons the search, which then stops early and returns nil.
+func FindDuplicates(path string, done <-chan struct{}, scan *Scan) []*DuplicateGroup {
+	bySize := make(map[int64][]*Entry)
+	if !findFiles(path, "", bySize, make(map[fileID]bool), done, scan) {
+		return nil
+	}
+
+	var groups []*DuplicateGroup
+	for size, entries := range bySize {
+		if len(entries) < 2 {
+			continue
+		}
+
+		for _, partial := range groupByHash(path, entries, partialHashSize, done, scan) {
+			for _, full := range groupByHash(path, partial, -1, done, scan) {
+				groups = append(groups, &DuplicateGroup{Size: size, Entries: full})
+			}
+		}
+	}
+	if abandoned(done) {
+		return nil
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Entries[0].Name < groups[j].Entries[0].Name
+	})
+	for _, group := range groups {
+		sort.Slice(group.Entries, func(i, j int) bool {
+			return group.Entries[i].Name < group.Entries[j].Name
+		})
+	}
+
+	return groups
+}
+
+// Reports whether the files at the given paths have identical contents.
+func SameContents(path, otherPath string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	otherFile, err := os.Open(otherPath)
+	if err != nil {
+		return false, err
+	}
+	defer otherFile.Close()
+
+	buffer, otherBuffer := make([]byte, 64*1024), make([]byte, 64*1024)
+	for {
+		length, err := io.ReadFull(file, buffer)
+		otherLength, otherErr := io.ReadFull(otherFile, otherBuffer)
+		if !bytes.Equal(buffer[:length], otherBuffer[:otherLength]) {
+			return false, nil
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return otherErr == err, nil
+		} else if err != nil {
+			return false, err
+		} else if otherErr != nil && otherErr != io.EOF && otherErr != io.ErrUnexpectedEOF {
+			return false, otherErr
+		}
+	}
+}
+
+// Structure identifying a file, so that hard links to it can be recognized.
+type fileID struct {
+	device, inode uint64
+}
+
+// Adds the (non-empty, regular) files in the directory at the given path (and its
+// subdirectories) to the map, keyed by size and named using the prefix, skipping
+// files that have already been seen (via another hard link). Returns false,
+// without finishing, if done is closed.
+func findFiles(path, prefix string, bySize map[int64][]*Entry, seen map[fileID]bool, done <-chan struct{}, scan *Scan) bool {
+	if abandoned(done) {
+		return false
+	}
+	entries := readDir(path)
+
+	var files, fileSize int64
+	for _, fileInfo := range entries {
+		if !fileInfo.Mode().IsRegular() || fileInfo.Size() == 0 {
+			continue
+		}
+		files++
+		fileSize += fileInfo.Size()
+
+		if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+			id := fileID{uint64(stat.Dev), uint64(stat.Ino)}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+
+		entry := NewEntry(fileInfo)
+		entry.Name = prefix + fileInfo.Name()
+		bySize[entry.Size] = append(bySize[entry.Size], entry)
+	}
+	scan.visit(path, files, fileSize)
+
+	for _, fileInfo := range entries {
+		if fileInfo.IsDir() && !findFiles(path+"/"+fileInfo.Name(), prefix+fileInfo.Name()+"/", bySize, seen, done, scan) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Splits the entries (named relative to the directory at the given path) into
+// groups with the same hash, using only the first limit bytes of each file (or
+// the whole file, if the limit is negative). Groups of one are left out, as are
+// files that can't be read. If done is closed, the files aren't grouped at all.
+func groupByHash(path string, entries []*Entry, limit int64, done <-chan struct{}, scan *Scan) [][]*Entry {
+	byHash := make(map[string][]*Entry)
+	var hashes []string
+	for _, entry := range entries {
+		hash, err := hashFile(path+"/"+entry.Name, limit, done, scan)
+		if err == errAbandoned {
+			return nil
+		} else if err != nil {
+			continue
+		}
+		if _, ok := byHash[hash]; !ok {
+			hashes = append(hashes, hash)
+		}
+		byHash[hash] = append(byHash[hash], entry)
+	}
+
+	var groups [][]*Entry
+	for _, hash := range hashes {
+		if len(byHash[hash]) > 1 {
+			groups = append(groups, byHash[hash])
+		}
+	}
+
+	return groups
+}
+
+// Returns a hash of the first limit bytes of the file at the given path, or
+// of the whole file if the limit is negative, reporting the bytes hashed to
+// the scan as it goes. Returns errAbandoned if done is closed part of the way.
+func hashFile(path string, limit int64, done <-chan struct{}, scan *Scan) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var source io.Reader = file
+	if limit >= 0 {
+		source = io.LimitReader(file, limit)
+	}
+
+	hash := sha256.New()
+	buffer := make([]byte, 1024*1024)
+	for {
+		if abandoned(done) {
+			return "", errAbandoned
+		}
+
+		length, err := source.Read(buffer)
+		hash.Write(buffer[:length])
+		scan.hash(path, int64(length))
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	return string(hash.Sum(nil)), nil
+}
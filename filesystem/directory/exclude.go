@@ -0,0 +1,196 @@
+package directory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/*
+ExcludeList holds patterns for entries that are left out of scans (e.g. ".git"
+or "*.snapshot"), using the syntax of .gitignore files: "*" and "?" match
+within a name, "[...]" matches a set of characters and "**" matches across
+directories. Patterns ending in a slash only match directories, and those
+starting with an exclamation mark re-include entries excluded by an earlier
+pattern; the last pattern that matches an entry decides whether it's excluded.
+
+Patterns without a slash (other than a trailing one) match entries at any
+depth by name. Since there's no single directory that the patterns belong
+to, patterns starting with a slash are matched against entries' absolute
+paths, and those containing one elsewhere (e.g. ".git/objects") are matched
+against the end of their paths.
+*/
+type ExcludeList struct {
+	patterns []excludePattern
+}
+
+// Structure holding a compiled exclude pattern.
+type excludePattern struct {
+	expression    *regexp.Regexp
+	negated       bool
+	directoryOnly bool
+}
+
+// Compiles the patterns (see ExcludeList), skipping blank
+// lines and comments (lines starting with a hash).
+func NewExcludeList(patterns []string) (*ExcludeList, error) {
+	list := &ExcludeList{}
+	for _, pattern := range patterns {
+		trimmed := strings.TrimSpace(pattern)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		compiled := excludePattern{}
+		if strings.HasPrefix(trimmed, "!") {
+			compiled.negated = true
+			trimmed = trimmed[1:]
+		}
+		if strings.HasSuffix(trimmed, "/") {
+			compiled.directoryOnly = true
+			trimmed = strings.TrimRight(trimmed, "/")
+		}
+		if trimmed == "" {
+			return nil, fmt.Errorf("invalid exclude pattern: %v", pattern)
+		}
+
+		expression, err := regexp.Compile(globExpression(trimmed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %v", pattern)
+		}
+		compiled.expression = expression
+		list.patterns = append(list.patterns, compiled)
+	}
+
+	return list, nil
+}
+
+// Translates a (trimmed) gitignore-style pattern into a regular expression
+// matching the absolute paths of the entries it applies to.
+func globExpression(pattern string) string {
+	var expression strings.Builder
+
+	// Patterns starting with a slash are absolute; any
+	// others can match at any depth.
+	if strings.HasPrefix(pattern, "/") {
+		expression.WriteString("^")
+	} else {
+		expression.WriteString("(^|/)")
+	}
+
+	for index := 0; index < len(pattern); index++ {
+		switch character := pattern[index]; character {
+		case '*':
+			if strings.HasPrefix(pattern[index:], "**/") {
+				// Leading and middle "**/" match zero or more directories.
+				expression.WriteString("(.*/)?")
+				index += 2
+			} else if strings.HasPrefix(pattern[index:], "**") {
+				expression.WriteString(".*")
+				index++
+			} else {
+				expression.WriteString("[^/]*")
+			}
+		case '?':
+			expression.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[index+1:], ']')
+			if end < 0 {
+				expression.WriteString(`\[`)
+				continue
+			}
+			class := pattern[index+1 : index+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			index += end + 1
+		case '\\':
+			if index+1 < len(pattern) {
+				index++
+				expression.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+			}
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(character)))
+		}
+	}
+	expression.WriteString("$")
+
+	return expression.String()
+}
+
+// Reports whether the entry at the given path is excluded, resolving
+// relative paths against the working directory, since patterns starting
+// with a slash match absolute paths. A nil list doesn't exclude anything.
+func (list *ExcludeList) Excludes(path string, isDirectory bool) bool {
+	if list == nil {
+		return false
+	}
+	if !filepath.IsAbs(path) {
+		if absolute, err := filepath.Abs(path); err == nil {
+			path = absolute
+		}
+	}
+
+	excluded := false
+	for _, pattern := range list.patterns {
+		if pattern.directoryOnly && !isDirectory {
+			continue
+		}
+		if pattern.expression.MatchString(path) {
+			excluded = !pattern.negated
+		}
+	}
+
+	return excluded
+}
+
+// The patterns honoured while scanning, guarded so that they can be
+// changed while directories are being walked in the background.
+var exclude struct {
+	sync.RWMutex
+	list *ExcludeList
+}
+
+// Sets the patterns for entries that are left out when directories are walked
+// (e.g. by Size or LargestFiles), and that aren't counted towards their totals.
+func SetExclude(list *ExcludeList) {
+	exclude.Lock()
+	defer exclude.Unlock()
+
+	exclude.list = list
+}
+
+// Reports whether the entry described by info, in the directory at the
+// given path, is excluded by the patterns set using SetExclude.
+func Excluded(directoryPath string, info os.FileInfo) bool {
+	exclude.RLock()
+	defer exclude.RUnlock()
+
+	return exclude.list.Excludes(directoryPath+"/"+info.Name(), info.IsDir())
+}
+
+// Reads the entries of the directory at the given path (as ioutil.ReadDir
+// does), leaving out those excluded by the patterns set using SetExclude.
+func readDir(path string) []os.FileInfo {
+	entries, _ := ioutil.ReadDir(path)
+
+	exclude.RLock()
+	defer exclude.RUnlock()
+	if exclude.list == nil {
+		return entries
+	}
+
+	included := entries[:0]
+	for _, entry := range entries {
+		if !exclude.list.Excludes(path+"/"+entry.Name(), entry.IsDir()) {
+			included = append(included, entry)
+		}
+	}
+
+	return included
+}
@@ -0,0 +1,111 @@
+package directory
+
+import (
+	"container/heap"
+	"os"
+)
+
+// Finds the largest files within the directory at the given path (at any
+// depth), returning entries for (at most) count of them, largest first.
+// The entries are named using their paths, relative to the directory.
+// Progress is reported to the scan as the directory is walked, unless it's nil.
+// Entries excluded using SetExclude are left out.
+func LargestFiles(path string, count int, scan *Scan) []*Entry {
+	return LargestFilesMatching(path, count, nil, nil, scan)
+}
+
+// Finds the largest files within the directory at the given path, like
+// LargestFiles, but only includes files that satisfy match (if it isn't
+// nil), e.g. those of a particular type or owner. Closing done abandons
+// the search, which then stops early and returns nil.
+func LargestFilesMatching(path string, count int, match func(info os.FileInfo) bool, done <-chan struct{}, scan *Scan) []*Entry {
+	largest := &entryHeap{}
+	if !findLargestFiles(path, "", count, match, largest, done, scan) {
+		return nil
+	}
+
+	// Popping the smallest entries first leaves the largest at the front.
+	entries := make([]*Entry, largest.Len())
+	for index := len(entries) - 1; index >= 0; index-- {
+		entries[index] = heap.Pop(largest).(*Entry)
+	}
+
+	return entries
+}
+
+// Adds the (matching) files in the directory at the given path (and its subdirectories)
+// to the heap, naming them using the prefix and trimming the heap down to count entries.
+// Returns false, without finishing, if done is closed.
+func findLargestFiles(path, prefix string, count int, match func(info os.FileInfo) bool, largest *entryHeap, done <-chan struct{}, scan *Scan) bool {
+	if abandoned(done) {
+		return false
+	}
+	entries := readDir(path)
+
+	var files, fileSize int64
+	for _, fileInfo := range entries {
+		if !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		files++
+		fileSize += fileInfo.Size()
+
+		if match != nil && !match(fileInfo) {
+			continue
+		}
+
+		// Skip files that are smaller than every file we've already kept.
+		if largest.Len() == count && (count == 0 || fileInfo.Size() <= (*largest)[0].Size) {
+			continue
+		}
+
+		entry := NewEntry(fileInfo)
+		entry.Name = prefix + fileInfo.Name()
+		heap.Push(largest, entry)
+		if largest.Len() > count {
+			heap.Pop(largest)
+		}
+	}
+	scan.visit(path, files, fileSize)
+
+	for _, fileInfo := range entries {
+		if fileInfo.IsDir() && !findLargestFiles(path+"/"+fileInfo.Name(), prefix+fileInfo.Name()+"/",
+			count, match, largest, done, scan) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// A min-heap of entries, ordered by size, used to keep
+// track of the largest entries found so far.
+type entryHeap []*Entry
+
+// Implement heap.Interface (and sort.Interface) length function.
+func (h entryHeap) Len() int {
+	return len(h)
+}
+
+// Implement heap.Interface comparison function, keeping the smallest entry on top.
+func (h entryHeap) Less(i, j int) bool {
+	return h[i].Size < h[j].Size
+}
+
+// Implement heap.Interface swap method.
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+// Implement heap.Interface push method.
+func (h *entryHeap) Push(entry interface{}) {
+	*h = append(*h, entry.(*Entry))
+}
+
+// Implement heap.Interface pop method, removing and returning the last entry.
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,75 @@
+package navigator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// Units accepted by ParseAge, in addition to those understood by time.ParseDuration.
+// None of them ends with another, or clashes with time.ParseDuration's (e.g. "m").
+var ageUnits = map[string]time.Duration{
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"mo": 30 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// Returns the navigator's active age filter (e.g. "180d"), or
+// an empty string if entries aren't being filtered by age.
+func (navigator *Navigator) AgeFilter() string {
+	return navigator.ageFilter
+}
+
+// Restricts the navigator's entries to those that haven't been used (modified
+// or, for files, accessed) within the given age, such as "180d" (see ParseAge),
+// so that stale data stands out. A directory's age covers everything within it,
+// and it's hidden once its size has been calculated if anything in it is recent.
+// An empty age removes the filter.
+func (navigator *Navigator) SetAgeFilter(age string) error {
+	var minimumAge time.Duration
+	if age != "" {
+		var err error
+		if minimumAge, err = ParseAge(age); err != nil {
+			return err
+		}
+	}
+
+	navigator.ageFilter = age
+	navigator.minimumAge = minimumAge
+	navigator.applyFilter()
+
+	return nil
+}
+
+// Parses an age given as a number of days ("180d"), weeks ("2w"),
+// months of 30 days ("6mo") or years of 365 days ("1y"), or as a
+// duration understood by time.ParseDuration (e.g. "12h" or "30m",
+// where "m" means minutes).
+func ParseAge(age string) (time.Duration, error) {
+	age = strings.TrimSpace(age)
+	for suffix, unit := range ageUnits {
+		if len(age) > len(suffix) && strings.HasSuffix(age, suffix) {
+			count, err := strconv.ParseFloat(age[:len(age)-len(suffix)], 64)
+			if err == nil && count >= 0 {
+				return time.Duration(count * float64(unit)), nil
+			}
+		}
+	}
+
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid age: %v", age)
+	}
+
+	return duration, nil
+}
+
+// Reports whether the entry passes the age filter,
+// having not been used within the minimum age.
+func (navigator *Navigator) oldEnough(entry *directory.Entry) bool {
+	return navigator.minimumAge == 0 || time.Since(entry.LastUsed()) >= navigator.minimumAge
+}
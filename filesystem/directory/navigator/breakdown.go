@@ -0,0 +1,211 @@
+package navigator
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// The name listed for files without an extension.
+const noExtension = "(no extension)"
+
+// Returned when acting on a set of files listed in a breakdown as if it were a file.
+var errBreakdownSelected = errors.New("the selected entry is a set of files, not a file")
+
+// Toggles between listing the current directory's entries and a breakdown
+// of the space used by the files below it, by extension (e.g. ".log").
+func (navigator *Navigator) ToggleExtensions() {
+	navigator.toggleBreakdown(extensionsListing)
+}
+
+// Toggles between listing the current directory's entries and a breakdown of
+// the space used by the files below it, by category (e.g. "video"; see
+// directory.Categories).
+func (navigator *Navigator) ToggleCategories() {
+	navigator.toggleBreakdown(categoriesListing)
+}
+
+// Toggles between listing the current directory's entries and a breakdown
+// of the space used by the files below it, by the user that owns them.
+func (navigator *Navigator) ToggleOwners() {
+	navigator.toggleBreakdown(ownersListing)
+}
+
+// Toggles between listing the current directory's entries and a breakdown
+// of the space used by the files below it, by the group that owns them.
+func (navigator *Navigator) ToggleGroups() {
+	navigator.toggleBreakdown(groupsListing)
+}
+
+// Switches to (or from) a breakdown of the space used by the files below the
+// current directory. Each set of files (e.g. of a type) is listed with its total
+// size and number of files, which are added up as directory sizes are calculated;
+// they can be sorted like any other entries, and opening one lists its largest files.
+func (navigator *Navigator) toggleBreakdown(kind listing) {
+	// Switching between breakdowns keeps the entries they're made up of.
+	sources := navigator.breakdownSources
+	if navigator.listing == directoryListing {
+		sources = navigator.allEntries
+	}
+	if !navigator.toggleListing(kind) {
+		return
+	}
+
+	if sources == nil {
+		navigator.readBreakdown()
+		return
+	}
+	navigator.breakdownSources = sources
+	navigator.listBreakdown()
+}
+
+// Reports whether a breakdown of the space used by the files below the current directory is listed.
+func (navigator *Navigator) breakdownListed() bool {
+	switch navigator.listing {
+	case extensionsListing, categoriesListing, ownersListing, groupsListing:
+		return true
+	}
+	return false
+}
+
+// Reads the current directory's entries (calculating directory sizes
+// afresh), and lists the breakdown of the files within them.
+func (navigator *Navigator) readBreakdown() {
+	// A directory that can no longer be read has nothing to break down.
+	dirEntries, _ := ioutil.ReadDir(navigator.currentPath + "/")
+	navigator.populateEntries(dirEntries)
+	navigator.breakdownSources = navigator.allEntries
+	navigator.listBreakdown()
+}
+
+// Lists the breakdown of the files within the current directory's entries, adding
+// up their totals, and keeping the selected set selected if it's still listed.
+func (navigator *Navigator) listBreakdown() {
+	var selectedName string
+	if entry := navigator.SelectedEntry(); entry != nil {
+		selectedName = entry.Name
+	}
+
+	totals := make(directory.TypeTotals)
+	for _, entry := range navigator.breakdownSources {
+		if !entry.Excluded {
+			navigator.addBreakdownTotals(totals, entry)
+		}
+	}
+
+	navigator.allEntries = make([]*directory.Entry, 0, len(totals))
+	for name, total := range totals {
+		navigator.allEntries = append(navigator.allEntries,
+			&directory.Entry{Name: name, Size: total.Size, Items: total.Files, SizeCalculated: true})
+	}
+	navigator.arrangeEntries()
+
+	for index, entry := range navigator.entries {
+		if entry.Name == selectedName {
+			navigator.selectedIndex = index
+		}
+	}
+}
+
+// Adds the entry's files to the totals, keyed by the names of the sets they're listed under.
+func (navigator *Navigator) addBreakdownTotals(totals directory.TypeTotals, entry *directory.Entry) {
+	switch navigator.listing {
+	case ownersListing:
+		for uid, total := range entry.OwnerTotals() {
+			totals.Add(directory.OwnerName(uid), total.Size, total.Files)
+		}
+	case groupsListing:
+		for gid, total := range entry.GroupTotals() {
+			totals.Add(directory.GroupName(gid), total.Size, total.Files)
+		}
+	default:
+		for extension, total := range entry.TypeTotals() {
+			totals.Add(navigator.fileType(extension), total.Size, total.Files)
+		}
+	}
+}
+
+// Returns the name of the type listed for files with the given extension.
+func (navigator *Navigator) fileType(extension string) string {
+	if navigator.listing == categoriesListing {
+		return directory.Category(extension)
+	}
+	if extension == "" {
+		return noExtension
+	}
+
+	return extension
+}
+
+// Lists the largest of the selected set of files below the current directory.
+// Toggling the largest files listing returns to the directory's entries.
+func (navigator *Navigator) showSelectedFiles() error {
+	entry := navigator.SelectedEntry()
+	if entry == nil {
+		return errNoSelection
+	}
+
+	name, kind := entry.Name, navigator.listing
+	switch kind {
+	case ownersListing:
+		navigator.largestFilesLabel = "files owned by " + name
+	case groupsListing:
+		navigator.largestFilesLabel = "files in group " + name
+	default:
+		navigator.largestFilesLabel = name + " files"
+	}
+	navigator.largestFilesMatch = func(info os.FileInfo) bool {
+		uid, gid := directory.Ownership(info)
+		switch kind {
+		case ownersListing:
+			return directory.OwnerName(uid) == name
+		case groupsListing:
+			return directory.GroupName(gid) == name
+		case categoriesListing:
+			return directory.Category(directory.Extension(info.Name())) == name
+		}
+		extension := directory.Extension(info.Name())
+		return extension == name || extension == "" && name == noExtension
+	}
+
+	// The directory's sort order has already been saved, to be restored later on.
+	navigator.listing = largestFilesListing
+	navigator.breakdownSources = nil
+	navigator.sortOrder.Mode = directory.SortBySize
+	navigator.sortOrder.Reverse = false
+	navigator.findLargestFiles()
+
+	return nil
+}
+
+// Returns the name displayed for a set of files in a breakdown, along with its number of files.
+func breakdownName(entry *directory.Entry) string {
+	if entry.Items == 1 {
+		return entry.Name + " (1 file)"
+	}
+
+	return fmt.Sprintf("%v (%d files)", entry.Name, entry.Items)
+}
+
+// Describes a breakdown in the status line, e.g. "12 extensions".
+func (navigator *Navigator) breakdownDescription() string {
+	var singular, plural string
+	switch navigator.listing {
+	case extensionsListing:
+		singular, plural = "extension", "extensions"
+	case categoriesListing:
+		singular, plural = "category", "categories"
+	case ownersListing:
+		singular, plural = "owner", "owners"
+	case groupsListing:
+		singular, plural = "group", "groups"
+	}
+
+	if len(navigator.allEntries) == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %v", len(navigator.allEntries), plural)
+}
@@ -0,0 +1,171 @@
+package navigator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+	"github.com/jmacdonald/purge/view"
+)
+
+// Returned when acting on the copies of an entry that isn't a duplicate.
+var errNotDuplicate = errors.New("the selected entry isn't a duplicate")
+
+// Toggles between listing the current directory's entries and the files below
+// it that have identical contents. The files are named using their paths relative
+// to the current directory, and are listed group by group (in order of the space
+// each group wastes) once they've been found.
+func (navigator *Navigator) ToggleDuplicates() {
+	if navigator.toggleListing(duplicatesListing) {
+		navigator.findDuplicates()
+	}
+}
+
+// Starts searching for duplicate files below the current directory in the
+// background, discarding the current entries and any pending size calculations.
+func (navigator *Navigator) findDuplicates() {
+	navigator.startSearch()
+
+	// Results from earlier searches are left behind on their own channels.
+	found := make(chan []*directory.DuplicateGroup, 1)
+	navigator.duplicatesFound = found
+	go func(path string, done <-chan struct{}, scan *directory.Scan) {
+		found <- directory.FindDuplicates(path, done, scan)
+	}(navigator.currentPath, navigator.abandonSearch, navigator.scan)
+}
+
+// Lists the duplicate files found by findDuplicates.
+func (navigator *Navigator) showDuplicates(groups []*directory.DuplicateGroup) {
+	navigator.duplicatesFound = nil
+	navigator.pendingCalculations = 0
+	navigator.allEntries = nil
+	navigator.duplicates = make(map[*directory.Entry]*directory.DuplicateGroup)
+	for _, group := range groups {
+		for _, entry := range group.Entries {
+			navigator.allEntries = append(navigator.allEntries, entry)
+			navigator.duplicates[entry] = group
+		}
+	}
+	navigator.applyFilter()
+}
+
+// Removes every copy of the selected duplicate except for the selected one,
+// returning the space freed. Copies are checked against the selected one
+// before they're removed, in case either has changed since it was found.
+func (navigator *Navigator) RemoveOtherDuplicates() (int64, error) {
+	return navigator.replaceOtherDuplicates(remove)
+}
+
+// Replaces every copy of the selected duplicate (except for the selected one)
+// with a hard link to the selected one, returning the space freed. Copies are
+// checked against the selected one before they're replaced, in case either
+// has changed since it was found.
+func (navigator *Navigator) LinkDuplicates() (int64, error) {
+	selectedPath := navigator.currentPath + "/"
+	if entry := navigator.SelectedEntry(); entry != nil {
+		selectedPath += entry.Name
+	}
+
+	return navigator.replaceOtherDuplicates(func(path string, entry *directory.Entry) error {
+		return link(selectedPath, path, entry)
+	})
+}
+
+// Replaces every copy of the selected duplicate (except for the selected one)
+// using the replace function, dropping the replaced copies from the listing.
+// Stops at the first copy that can't be replaced, returning the space freed.
+func (navigator *Navigator) replaceOtherDuplicates(replace func(path string, entry *directory.Entry) error) (freed int64, err error) {
+	selectedEntry := navigator.SelectedEntry()
+	if selectedEntry == nil {
+		return 0, errNoSelection
+	}
+	group, ok := navigator.duplicates[selectedEntry]
+	if !ok {
+		return 0, errNotDuplicate
+	}
+	defer navigator.arrangeEntries()
+
+	selectedPath := navigator.currentPath + "/" + selectedEntry.Name
+	for _, entry := range append([]*directory.Entry(nil), group.Entries...) {
+		if entry == selectedEntry {
+			continue
+		}
+		path := navigator.currentPath + "/" + entry.Name
+
+		same, err := directory.SameContents(selectedPath, path)
+		if err != nil {
+			return freed, err
+		} else if !same {
+			return freed, fmt.Errorf("%v has changed", entry.Name)
+		}
+
+		if err = replace(path, entry); err != nil {
+			return freed, err
+		}
+		freed += entry.Size
+		navigator.dropEntry(entry)
+	}
+
+	return freed, nil
+}
+
+// Stops listing an entry as a duplicate, dropping the last copy
+// left in its group, since it's no longer a duplicate either.
+func (navigator *Navigator) dropDuplicate(removedEntry *directory.Entry) {
+	group, ok := navigator.duplicates[removedEntry]
+	if !ok {
+		return
+	}
+	delete(navigator.duplicates, removedEntry)
+
+	for index, entry := range group.Entries {
+		if entry == removedEntry {
+			group.Entries = append(group.Entries[:index], group.Entries[index+1:]...)
+			break
+		}
+	}
+	if len(group.Entries) == 1 {
+		navigator.dropEntry(group.Entries[0])
+	}
+}
+
+// Returns the guide characters that precede a duplicate's name,
+// joining it to the other copies in its group.
+func (navigator *Navigator) duplicateGuide(entry *directory.Entry) (string, bool) {
+	group, ok := navigator.duplicates[entry]
+	if !ok {
+		return "", false
+	}
+
+	switch entry {
+	case group.Entries[0]:
+		return "┌─ ", true
+	case group.Entries[len(group.Entries)-1]:
+		return "└─ ", true
+	}
+	return "├─ ", true
+}
+
+// Describes the duplicates listing in the status line,
+// e.g. "3 groups of duplicates, 1.2 GB wasted".
+func (navigator *Navigator) duplicatesDescription() string {
+	if navigator.duplicatesFound != nil {
+		return "Duplicates"
+	}
+
+	groups := make(map[*directory.DuplicateGroup]bool)
+	var wasted int64
+	for _, group := range navigator.duplicates {
+		if !groups[group] {
+			groups[group] = true
+			wasted += group.Wasted()
+		}
+	}
+
+	noun := "groups"
+	if len(groups) == 1 {
+		noun = "group"
+	}
+
+	return fmt.Sprintf("%d %v of duplicates, %v wasted", len(groups), noun, view.Size(wasted))
+}
@@ -0,0 +1,59 @@
+package navigator
+
+import (
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// Reports whether entries excluded from scans (see directory.SetExclude)
+// are listed, rather than hidden.
+func (navigator *Navigator) ExcludedRevealed() bool {
+	return navigator.revealExcluded
+}
+
+// Toggles listing the entries that are excluded from scans. They're hidden by
+// default, and listed (and styled) separately once revealed, with their sizes
+// calculated on demand; they're never counted towards the sizes of the
+// directories they're in.
+func (navigator *Navigator) ToggleExcluded() {
+	navigator.revealExcluded = !navigator.revealExcluded
+
+	// Calculate the sizes of the excluded directories that have been skipped until now.
+	if navigator.revealExcluded {
+		for _, entry := range navigator.allEntries {
+			navigator.calculateExcludedSize(entry)
+		}
+		for _, children := range navigator.expanded {
+			for _, entry := range children {
+				navigator.calculateExcludedSize(entry)
+			}
+		}
+	}
+	navigator.arrangeEntries()
+}
+
+// Starts calculating the size of an excluded directory, if it hasn't been already.
+func (navigator *Navigator) calculateExcludedSize(entry *directory.Entry) {
+	if entry.Excluded && entry.IsDirectory && !entry.SizeCalculated && !entry.Provisional {
+		navigator.calculateSize(entry)
+	}
+}
+
+// Reports whether the entry is listed, which excluded entries
+// are only once they've been revealed.
+func (navigator *Navigator) revealed(entry *directory.Entry) bool {
+	return !entry.Excluded || navigator.revealExcluded
+}
+
+// Returns the number of the directory's entries that are excluded and hidden.
+func (navigator *Navigator) hiddenExcludedCount() (count int) {
+	if navigator.revealExcluded || navigator.listing != directoryListing {
+		return 0
+	}
+	for _, entry := range navigator.allEntries {
+		if entry.Excluded {
+			count++
+		}
+	}
+
+	return count
+}
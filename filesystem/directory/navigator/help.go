@@ -0,0 +1,76 @@
+package navigator
+
+import (
+	"strings"
+
+	"github.com/jmacdonald/purge/input"
+	"github.com/jmacdonald/purge/view"
+)
+
+// Displays the help overlay in place of the entries.
+func (navigator *Navigator) ShowHelp() {
+	navigator.helpVisible = true
+}
+
+// Dismisses the help overlay.
+func (navigator *Navigator) HideHelp() {
+	navigator.helpVisible = false
+}
+
+// Reports whether the help overlay is being displayed.
+func (navigator *Navigator) HelpVisible() bool {
+	return navigator.helpVisible
+}
+
+// Generates a buffer describing the active key map. If there are more bindings
+// than rows, they're spread over as many columns as it takes to list them all,
+// dividing the screen's width evenly between them.
+func (navigator *Navigator) helpView(maxRows int) *view.Buffer {
+	status := [2]string{"Help", "Press any key to return"}
+	if maxRows < 1 {
+		return &view.Buffer{Status: status}
+	}
+
+	// Describe each binding, padding the keys so that descriptions line up.
+	bindings := input.Bindings()
+	lines := make([]string, 0, len(bindings)+2)
+	keyWidth := 0
+	for _, binding := range bindings {
+		if width := view.Width(strings.Join(binding.Keys, ", ")); width > keyWidth {
+			keyWidth = width
+		}
+	}
+	for _, binding := range bindings {
+		keys := strings.Join(binding.Keys, ", ")
+		lines = append(lines, keys+strings.Repeat(" ", keyWidth-view.Width(keys))+"  "+binding.Description)
+	}
+	lines = append(lines, "", "Movement keys can be preceded by a count, e.g. 5j.")
+
+	// Lines that fit in a single column are left for the view to lay out.
+	columnCount := (len(lines) + maxRows - 1) / maxRows
+	rowCount := (len(lines) + columnCount - 1) / columnCount
+	rows := make([]view.Row, rowCount)
+	if columnCount == 1 {
+		for index := range rows {
+			rows[index].Left = lines[index]
+		}
+		return &view.Buffer{Rows: rows, Status: status}
+	}
+
+	// Otherwise, fill the columns from top to bottom, giving each the same width
+	// (so that they line up), and truncating descriptions that don't fit.
+	width := view.ScreenWidth()
+	columnWidth := (width - (columnCount - 1)) / columnCount
+	for index := range rows {
+		columns := make([]view.Column, columnCount)
+		for column := range columns {
+			columns[column].Width = columnWidth
+			if line := index + column*rowCount; line < len(lines) {
+				columns[column].Text = lines[line]
+			}
+		}
+		rows[index].Left = view.FormatColumns(columns, width)
+	}
+
+	return &view.Buffer{Rows: rows, Status: status}
+}
@@ -0,0 +1,77 @@
+package navigator
+
+import (
+	"strings"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// HideHidden determines whether hidden entries (those with names starting
+// with a dot) are left out of listings when the navigator starts. They're
+// still counted towards the sizes of the directories they're in.
+var HideHidden bool
+
+// Reports whether hidden entries are listed, rather than left out.
+func (navigator *Navigator) HiddenShown() bool {
+	return !navigator.hideHidden
+}
+
+// Toggles leaving hidden entries (e.g. dotfiles) out of the listing. Their
+// sizes are still calculated, and counted towards the sizes of the
+// directories they're in (and their share of the current directory's size).
+func (navigator *Navigator) ToggleHidden() {
+	navigator.hideHidden = !navigator.hideHidden
+	navigator.arrangeEntries()
+}
+
+// Reports whether the entry is hidden: if it's named (or, for entries named by
+// their paths below the current directory, is within a directory named) with
+// a leading dot. Breakdowns of the space used by files don't list entries of
+// their own, so nothing is hidden in them.
+func (navigator *Navigator) hidden(entry *directory.Entry) bool {
+	if !navigator.hideHidden || navigator.breakdownListed() {
+		return false
+	}
+
+	for _, name := range strings.Split(entry.Name, "/") {
+		if strings.HasPrefix(name, ".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports whether the entry is listed: excluded entries are only
+// listed once revealed, and hidden ones unless they're being left out.
+func (navigator *Navigator) listable(entry *directory.Entry) bool {
+	return navigator.revealed(entry) && !navigator.hidden(entry)
+}
+
+// Returns the number of entries that are hidden, for display in the status line:
+// the directory's own, and those of expanded directories listed in the tree.
+// Excluded entries that haven't been revealed are counted separately (see
+// hiddenExcludedCount), so they're left out.
+func (navigator *Navigator) hiddenCount() int {
+	count := navigator.countHidden(navigator.allEntries)
+	if navigator.treeListed() {
+		for _, entry := range navigator.entries {
+			if children, expanded := navigator.expanded[entry.Name]; expanded {
+				count += navigator.countHidden(children)
+			}
+		}
+	}
+
+	return count
+}
+
+// Returns the number of the entries that are hidden, but not excluded.
+func (navigator *Navigator) countHidden(entries []*directory.Entry) (count int) {
+	for _, entry := range entries {
+		if navigator.revealed(entry) && navigator.hidden(entry) {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,58 @@
+package navigator
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// The number of files listed when showing the largest files below the current directory.
+var LargestFileCount = 100
+
+// Toggles between listing the current directory's entries and the largest
+// files anywhere below it. The files are named using their paths relative to
+// the current directory, and are listed largest first once they've been found;
+// the previous sort order is restored when the directory is listed again.
+func (navigator *Navigator) ToggleLargestFiles() {
+	if navigator.toggleListing(largestFilesListing) {
+		navigator.sortOrder.Mode = directory.SortBySize
+		navigator.sortOrder.Reverse = false
+		navigator.findLargestFiles()
+	}
+}
+
+// Starts searching for the largest files below the current directory in the
+// background, discarding the current entries and any pending size calculations.
+func (navigator *Navigator) findLargestFiles() {
+	navigator.startSearch()
+
+	// Results from earlier searches are left behind on their own channels.
+	found := make(chan []*directory.Entry, 1)
+	navigator.largestFilesFound = found
+	go func(path string, match func(info os.FileInfo) bool, done <-chan struct{}, scan *directory.Scan) {
+		found <- directory.LargestFilesMatching(path, LargestFileCount, match, done, scan)
+	}(navigator.currentPath, navigator.largestFilesMatch, navigator.abandonSearch, navigator.scan)
+}
+
+// Lists the largest files found by findLargestFiles.
+func (navigator *Navigator) showLargestFiles(entries []*directory.Entry) {
+	navigator.largestFilesFound = nil
+	navigator.pendingCalculations = 0
+	navigator.allEntries = entries
+	navigator.applyFilter()
+}
+
+// Describes the largest files listing in the status line, e.g. "100 largest
+// files", including the set of files they're from (e.g. ".log files"), if any.
+func (navigator *Navigator) largestFilesDescription() string {
+	files := "files"
+	if navigator.largestFilesLabel != "" {
+		files = navigator.largestFilesLabel
+	}
+	if navigator.largestFilesFound != nil {
+		return "Largest " + files
+	}
+
+	return fmt.Sprintf("%d largest %v", len(navigator.allEntries), files)
+}
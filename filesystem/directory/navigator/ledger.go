@@ -0,0 +1,75 @@
+package navigator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmacdonald/purge/view"
+)
+
+// Structure describing an entry removed from the filesystem.
+type Removal struct {
+	Path string
+	Size int64
+	Time time.Time
+}
+
+// Ledger keeps track of the entries removed during a session,
+// and is safe for concurrent use.
+type Ledger struct {
+	removals []Removal
+	mutex    sync.Mutex
+}
+
+// Session records every removal made by navigators in this process,
+// so that the space freed can be summarized on exit.
+var Session = new(Ledger)
+
+// Record adds a removal of the specified size to the ledger.
+func (ledger *Ledger) Record(path string, size int64) {
+	ledger.mutex.Lock()
+	defer ledger.mutex.Unlock()
+
+	ledger.removals = append(ledger.removals, Removal{Path: path, Size: size, Time: time.Now()})
+}
+
+// Removals returns the recorded removals, oldest first.
+func (ledger *Ledger) Removals() []Removal {
+	ledger.mutex.Lock()
+	defer ledger.mutex.Unlock()
+
+	return append([]Removal(nil), ledger.removals...)
+}
+
+// Freed returns the total size of the recorded removals.
+func (ledger *Ledger) Freed() (total int64) {
+	for _, removal := range ledger.Removals() {
+		total += removal.Size
+	}
+
+	return
+}
+
+// Summary describes the recorded removals and the total space they
+// freed, one removal per line, or returns an empty string if there
+// haven't been any.
+func (ledger *Ledger) Summary() string {
+	removals := ledger.Removals()
+	if len(removals) == 0 {
+		return ""
+	}
+
+	noun := "entries"
+	if len(removals) == 1 {
+		noun = "entry"
+	}
+
+	summary := fmt.Sprintf("Freed %v by removing %d %v:\n", view.Size(ledger.Freed()), len(removals), noun)
+	for _, removal := range removals {
+		summary += fmt.Sprintf("  %v  %v  %v\n", removal.Time.Format("15:04:05"),
+			view.Size(removal.Size), removal.Path)
+	}
+
+	return summary
+}
@@ -0,0 +1,93 @@
+package navigator
+
+import (
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// Kinds of listing shown by the navigator: the current directory's own entries,
+// files found anywhere below it, or breakdowns of those files (e.g. by type).
+type listing int
+
+const (
+	directoryListing listing = iota
+	largestFilesListing
+	duplicatesListing
+	extensionsListing
+	categoriesListing
+	ownersListing
+	groupsListing
+)
+
+// Switches from listing the current directory's entries to another kind of
+// listing, or back again if that kind of listing is already being shown.
+// Reports whether the other kind of listing should be started.
+func (navigator *Navigator) toggleListing(kind listing) bool {
+	if navigator.listing == kind {
+		navigator.SetWorkingDirectory(navigator.currentPath)
+		return false
+	}
+	navigator.stopListing()
+
+	// The listing no longer reflects the directory's own entries,
+	// so there's nothing for the watcher to keep up to date.
+	navigator.stopWatching()
+	navigator.listing = kind
+	navigator.listingSortOrder = navigator.sortOrder
+
+	return true
+}
+
+// Discards the current entries and any pending size calculations (or searches),
+// so that a listing can be searched for. The search is reported as a pending
+// calculation until it's finished, with its progress reported to the scan.
+// Closing abandonSearch abandons it.
+func (navigator *Navigator) startSearch() {
+	navigator.abandonSearches()
+	navigator.abandonSearch = make(chan struct{})
+	navigator.allEntries = nil
+	navigator.duplicates = nil
+	navigator.resetCalculations(0)
+	navigator.pendingCalculations = 1
+	navigator.scan = directory.NewScan()
+	navigator.applyFilter()
+	navigator.scheduleProgress()
+}
+
+// Tells the search for the files being listed to stop, if one is underway.
+func (navigator *Navigator) abandonSearches() {
+	if navigator.abandonSearch != nil {
+		close(navigator.abandonSearch)
+		navigator.abandonSearch = nil
+	}
+}
+
+// Stops showing a listing other than the directory's own entries, if one is
+// being shown, restoring the sort order used for the directory's entries.
+// Searches that are still underway are abandoned, and their results discarded.
+func (navigator *Navigator) stopListing() {
+	navigator.abandonSearches()
+	if navigator.listing != directoryListing {
+		navigator.sortOrder = navigator.listingSortOrder
+	}
+	navigator.listing = directoryListing
+	navigator.largestFilesFound = nil
+	navigator.largestFilesLabel = ""
+	navigator.largestFilesMatch = nil
+	navigator.duplicatesFound = nil
+	navigator.duplicates = nil
+	navigator.breakdownSources = nil
+}
+
+// Describes the listing in the status line, or returns an
+// empty string if the directory's own entries are listed.
+func (navigator *Navigator) listingDescription() string {
+	switch navigator.listing {
+	case largestFilesListing:
+		return navigator.largestFilesDescription()
+	case duplicatesListing:
+		return navigator.duplicatesDescription()
+	case extensionsListing, categoriesListing, ownersListing, groupsListing:
+		return navigator.breakdownDescription()
+	}
+	return ""
+}
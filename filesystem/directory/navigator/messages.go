@@ -0,0 +1,89 @@
+package navigator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jmacdonald/purge/view"
+)
+
+// How long messages are displayed before being cleared automatically.
+var MessageDuration = 4 * time.Second
+
+// Returns the message displayed in the status line, if any.
+func (navigator *Navigator) Message() *view.Message {
+	return navigator.message
+}
+
+// Displays a message in the status line, replacing any existing message.
+// It's cleared after MessageDuration, or when the next command arrives.
+func (navigator *Navigator) notify(severity view.Severity, format string, arguments ...interface{}) {
+	navigator.message = &view.Message{Text: fmt.Sprintf(format, arguments...), Severity: severity}
+	navigator.messageExpired = time.After(MessageDuration)
+}
+
+// Displays an error message, if there's an error to display.
+func (navigator *Navigator) notifyError(err error) {
+	if err != nil {
+		navigator.notify(view.Failure, "%s", errorMessage(err))
+	}
+}
+
+// Removes the displayed message, if any.
+func (navigator *Navigator) clearMessage() {
+	navigator.message = nil
+	navigator.messageExpired = nil
+}
+
+// Removes the selected entry, notifying the user of the outcome.
+func (navigator *Navigator) removeSelectedEntry() {
+	entry := navigator.SelectedEntry()
+	if err := navigator.RemoveSelectedEntry(); err != nil {
+		navigator.notifyError(err)
+	} else {
+		navigator.notify(view.Info, "Deleted %s (%s freed)", entry.Name, view.Size(entry.Size))
+	}
+}
+
+// Removes the other copies of the selected duplicate, notifying the user of the outcome.
+func (navigator *Navigator) removeOtherDuplicates() {
+	entry := navigator.SelectedEntry()
+	if freed, err := navigator.RemoveOtherDuplicates(); err != nil {
+		navigator.notifyError(err)
+	} else {
+		navigator.notify(view.Info, "Deleted the other copies of %s (%s freed)", entry.Name, view.Size(freed))
+	}
+}
+
+// Replaces the other copies of the selected duplicate with hard
+// links to it, notifying the user of the outcome.
+func (navigator *Navigator) linkDuplicates() {
+	entry := navigator.SelectedEntry()
+	if freed, err := navigator.LinkDuplicates(); err != nil {
+		navigator.notifyError(err)
+	} else {
+		navigator.notify(view.Info, "Linked the other copies of %s (%s freed)", entry.Name, view.Size(freed))
+	}
+}
+
+// Describes an error for display, in the form "Permission denied: name".
+func errorMessage(err error) string {
+	var message string
+	switch err := err.(type) {
+	case *os.PathError:
+		message = err.Err.Error() + ": " + filepath.Base(err.Path)
+	case *os.LinkError:
+		message = err.Err.Error() + ": " + filepath.Base(err.Old)
+	default:
+		message = err.Error()
+	}
+
+	if message == "" {
+		return message
+	}
+
+	return strings.ToUpper(message[:1]) + message[1:]
+}
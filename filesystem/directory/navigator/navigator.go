@@ -6,10 +6,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"sort"
 	"syscall"
+	"time"
 
+	"github.com/jmacdonald/purge/audit"
 	"github.com/jmacdonald/purge/filesystem/directory"
+	"github.com/jmacdonald/purge/input"
 	"github.com/jmacdonald/purge/view"
 )
 
@@ -19,62 +21,220 @@ type Navigator struct {
 	currentPath         string
 	selectedIndex       int
 	entries             []*directory.Entry
+	allEntries          []*directory.Entry
 	viewDataIndices     [2]int
 	view                chan<- *view.Buffer
 	DirectorySizes      chan *directory.EntrySize
+	abandonSizes        chan struct{}
 	pendingCalculations int
+	prompt              *prompt
+	searchTerm          string
+	filter              string
+	filterMatch         func(name string) bool
+	ageFilter           string
+	revealExcluded      bool
+	hideHidden          bool
+	minimumAge          time.Duration
+	sortOrder           directory.SortOrder
+	manualSort          bool
+	helpVisible         bool
+	message             *view.Message
+	messageExpired      <-chan time.Time
+	scan                *directory.Scan
+	progressTick        <-chan time.Time
+	watching            bool
+	watcher             *directory.Watcher
+	watchChanges        <-chan string
+	changes             map[string]bool
+	changeTimer         <-chan time.Time
+	listing             listing
+	abandonSearch       chan struct{}
+	largestFilesFound   <-chan []*directory.Entry
+	largestFilesLabel   string
+	largestFilesMatch   func(info os.FileInfo) bool
+	duplicatesFound     <-chan []*directory.DuplicateGroup
+	duplicates          map[*directory.Entry]*directory.DuplicateGroup
+	listingSortOrder    directory.SortOrder
+	breakdownSources    []*directory.Entry
+	tree                bool
+	expanded            map[string][]*directory.Entry
+	treeGuides          map[*directory.Entry]string
 }
 
-// NewNavigator constructs a new navigator object and waits indefinitely
-// for commands sent to it. It sends an updated buffer whenever the
-// navigator changes state.
+// AuditLog, if set, is used to log every removal.
+var AuditLog *audit.Log
+
+// Returned when acting on the selected entry in an empty directory.
+var errNoSelection = errors.New("no entry selected")
+
+// NewNavigator constructs a new navigator object and waits for commands
+// sent to it, until the command channel is closed. It sends an updated
+// buffer whenever the navigator changes state.
 // This function is meant to be run in a goroutine.
-func NewNavigator(path string, commands <-chan string, buffers chan<- *view.Buffer) {
+func NewNavigator(path string, commands <-chan input.Command, buffers chan<- *view.Buffer) {
 	navigator := new(Navigator)
 
 	// Link the navigator up to the view.
 	navigator.view = buffers
+	navigator.hideHidden = HideHidden
+
+	// Keep up with changes to the directories we visit.
+	navigator.watching = true
+	defer navigator.stopWatching()
+	defer navigator.abandonCalculations()
+	defer navigator.abandonSearches()
 
 	// Set the initial working directory using
 	// the path passed in as an argument.
-	navigator.SetWorkingDirectory(path)
+	navigator.notifyError(navigator.SetWorkingDirectory(path))
 
 	for {
 		select {
-		case command := <-commands: // A command has arrived.
+		case command, ok := <-commands: // A command has arrived.
+			// Stop once the command channel has been closed.
+			if !ok {
+				return
+			}
+
+			// Any command dismisses the displayed message.
+			navigator.clearMessage()
+
+			// Commands without a count are performed once.
+			count := command.Count
+			if count < 1 {
+				count = 1
+			}
+
 			// Invoke the command on the navigator.
-			switch command {
+			switch command.Name {
 			case "SelectNextEntry":
-				navigator.SelectNextEntry()
+				navigator.SelectEntryOffset(count)
 			case "SelectLastEntry":
-				navigator.SelectLastEntry()
+				// A count selects that entry (starting at one), like Vim's "G".
+				if command.Count > 0 {
+					navigator.SelectEntry(command.Count - 1)
+				} else {
+					navigator.SelectLastEntry()
+				}
 			case "SelectPreviousEntry":
-				navigator.SelectPreviousEntry()
+				navigator.SelectEntryOffset(-count)
 			case "SelectFirstEntry":
-				navigator.SelectFirstEntry()
+				if command.Count > 0 {
+					navigator.SelectEntry(command.Count - 1)
+				} else {
+					navigator.SelectFirstEntry()
+				}
+			case "SelectNextHalfPage":
+				for i := 0; i < count; i++ {
+					navigator.SelectNextHalfPage(view.Height())
+				}
+			case "SelectPreviousHalfPage":
+				for i := 0; i < count; i++ {
+					navigator.SelectPreviousHalfPage(view.Height())
+				}
 			case "SortEntries":
 				navigator.SortEntries()
+			case "ReverseSortOrder":
+				navigator.ReverseSortOrder()
+			case "ToggleDirectoriesFirst":
+				navigator.ToggleDirectoriesFirst()
+			case "ToggleAutoSort":
+				navigator.ToggleAutoSort()
+			case "ShowHelp":
+				navigator.ShowHelp()
+			case "HideHelp":
+				navigator.HideHelp()
 			case "IntoSelectedEntry":
-				navigator.IntoSelectedEntry()
+				navigator.notifyError(navigator.IntoSelectedEntry())
 			case "ToParentDirectory":
-				navigator.ToParentDirectory()
+				// Stop climbing as soon as we can't go any further, without
+				// re-reading the root directory once we've reached it.
+				for i := 0; i < count && filepath.Dir(navigator.CurrentPath()) != navigator.CurrentPath(); i++ {
+					if err := navigator.ToParentDirectory(); err != nil {
+						navigator.notifyError(err)
+						break
+					}
+				}
 			case "RemoveSelectedEntry":
-				navigator.RemoveSelectedEntry()
+				navigator.removeSelectedEntry()
+			case "ToggleTree":
+				navigator.ToggleTree()
+			case "ToggleSelectedEntryExpanded":
+				navigator.notifyError(navigator.ToggleSelectedEntryExpanded())
+			case "ToggleLargestFiles":
+				navigator.ToggleLargestFiles()
+			case "ToggleExtensions":
+				navigator.ToggleExtensions()
+			case "ToggleCategories":
+				navigator.ToggleCategories()
+			case "ToggleOwners":
+				navigator.ToggleOwners()
+			case "ToggleGroups":
+				navigator.ToggleGroups()
+			case "ToggleHidden":
+				navigator.ToggleHidden()
+			case "ToggleExcluded":
+				navigator.ToggleExcluded()
+			case "ToggleDuplicates":
+				navigator.ToggleDuplicates()
+			case "RemoveOtherDuplicates":
+				navigator.removeOtherDuplicates()
+			case "LinkDuplicates":
+				navigator.linkDuplicates()
+			case "Refresh":
+				navigator.Refresh()
+			case "RescanSelectedEntry":
+				navigator.notifyError(navigator.RescanSelectedEntry())
+			case "Search", "Filter", "AgeFilter":
+				navigator.openPrompt(command.Name)
+			case "UpdatePrompt":
+				navigator.updatePrompt(command.Argument)
+			case "ConfirmPrompt":
+				navigator.notifyError(navigator.confirmPrompt(command.Argument))
+			case "CancelPrompt":
+				navigator.cancelPrompt()
+			case "SelectNextMatch":
+				for i := 0; i < count; i++ {
+					navigator.SelectNextMatch()
+				}
+			case "SelectPreviousMatch":
+				for i := 0; i < count; i++ {
+					navigator.SelectPreviousMatch()
+				}
 			}
 
 			// Refresh the view.
 			buffers <- navigator.View(view.Height())
 
 		case directorySize := <-navigator.DirectorySizes: // A directory size calculation has completed.
-			// Update the stored entry size and flag it as calculated.
-			navigator.entries[directorySize.Index].Size = directorySize.Size
-			navigator.entries[directorySize.Index].SizeCalculated = true
-
-			// Reduce this count so the view increases the completion percentage.
-			navigator.pendingCalculations--
+			navigator.updateEntrySize(directorySize)
 
 			// Update the view, since we have another directory size.
 			navigator.view <- navigator.View(view.Height())
+
+		case <-navigator.progressTick: // It's time to report on the scan's progress.
+			navigator.progressTick = nil
+			navigator.scheduleProgress()
+			navigator.view <- navigator.View(view.Height())
+
+		case entries := <-navigator.largestFilesFound: // The largest files have been found.
+			navigator.showLargestFiles(entries)
+			navigator.view <- navigator.View(view.Height())
+
+		case groups := <-navigator.duplicatesFound: // The duplicate files have been found.
+			navigator.showDuplicates(groups)
+			navigator.view <- navigator.View(view.Height())
+
+		case name := <-navigator.watchChanges: // An entry has changed on disk.
+			navigator.recordChange(name)
+
+		case <-navigator.changeTimer: // It's time to apply the changes collected.
+			navigator.applyChanges()
+			navigator.view <- navigator.View(view.Height())
+
+		case <-navigator.messageExpired: // The displayed message has expired.
+			navigator.clearMessage()
+			navigator.view <- navigator.View(view.Height())
 		}
 	}
 }
@@ -89,8 +249,9 @@ func (navigator *Navigator) SelectedIndex() int {
 	return navigator.selectedIndex
 }
 
-// Returns the navigator's current directory entries. This method does
-// not read from disk and may not accurately reflect filesystem contents.
+// Returns the navigator's current directory entries, excluding any that don't
+// match the active filter. This method does not read from disk and may not
+// accurately reflect filesystem contents.
 func (navigator *Navigator) Entries() []*directory.Entry {
 	return navigator.entries
 }
@@ -98,7 +259,7 @@ func (navigator *Navigator) Entries() []*directory.Entry {
 // Returns the navigator's currently selected entry.
 func (navigator *Navigator) SelectedEntry() *directory.Entry {
 	// Prevent an empty directory from accessing an out-of-bounds index.
-	if navigator.SelectedIndex() < len(navigator.Entries()) {
+	if navigator.SelectedIndex() >= 0 && navigator.SelectedIndex() < len(navigator.Entries()) {
 		return navigator.Entries()[navigator.SelectedIndex()]
 	}
 
@@ -114,6 +275,7 @@ func (navigator *Navigator) ViewDataIndices() [2]int {
 // Sets the navigator's current directory path,
 // fetches the entries for the newly changed directory,
 // and resets the selected index to zero (if the directory is valid).
+// If the directory can't be read, the current one is kept.
 func (navigator *Navigator) SetWorkingDirectory(path string) (error error) {
 	file, error := os.Stat(path)
 	if error == nil && file.IsDir() {
@@ -122,10 +284,25 @@ func (navigator *Navigator) SetWorkingDirectory(path string) (error error) {
 			path = path[:len(path)-1]
 		}
 
+		// Read the directory entries before leaving the current directory.
+		var dirEntries []os.FileInfo
+		if dirEntries, error = ioutil.ReadDir(path + "/"); error != nil {
+			return
+		}
+
 		navigator.currentPath = path
 		navigator.selectedIndex = 0
 		navigator.viewDataIndices = [2]int{0, 0}
-		navigator.populateEntries()
+		navigator.prompt = nil
+		navigator.filter = ""
+		navigator.filterMatch = nil
+		navigator.stopListing()
+		navigator.expanded = nil
+		navigator.populateEntries(dirEntries)
+
+		if navigator.watching {
+			navigator.startWatching()
+		}
 	} else if error == nil {
 		error = errors.New("path is not a directory")
 	}
@@ -133,46 +310,194 @@ func (navigator *Navigator) SetWorkingDirectory(path string) (error error) {
 	return
 }
 
-func (navigator *Navigator) populateEntries() {
-	var size int64
+// Lists the current directory's entries (as read by ioutil.ReadDir),
+// and starts calculating the sizes of its directories.
+func (navigator *Navigator) populateEntries(dirEntries []os.FileInfo) {
+	navigator.allEntries = make([]*directory.Entry, len(dirEntries))
 
-	// Read the directory entries.
-	dirEntries, _ := ioutil.ReadDir(navigator.currentPath + "/")
-	navigator.entries = make([]*directory.Entry, len(dirEntries))
+	// Allocate a buffered channel on which we'll receive directory sizes
+	// from size-calculating goroutines, abandoning any still underway.
+	navigator.resetCalculations(len(dirEntries))
+
+	for index, dirEntry := range dirEntries {
+		entry := newEntry(navigator.currentPath+"/"+dirEntry.Name(), dirEntry)
+		navigator.allEntries[index] = entry
+
+		// Directory sizes need to be calculated separately.
+		if entry.IsDirectory {
+			navigator.calculateSize(entry)
+		}
+	}
+	navigator.applyFilter()
+
+	// Update the view, since we have sizes for files.
+	navigator.view <- navigator.View(view.Height())
+}
 
-	// Allocate a buffered channel on which we'll receive
-	// directory sizes from size-calculating goroutines.
-	navigator.DirectorySizes = make(chan *directory.EntrySize, len(dirEntries))
+// Builds an entry for the file at the given path, following symlinks, and
+// falling back to the link itself (described by linkInfo, as returned by
+// Lstat) if its target (or the entry) can't be read. Entries matching the
+// exclude patterns are flagged as excluded.
+func newEntry(path string, linkInfo os.FileInfo) *directory.Entry {
+	entryInfo, err := os.Stat(path)
+	if err != nil {
+		entryInfo = linkInfo
+	}
+
+	entry := directory.NewEntry(entryInfo)
+	entry.IsSymlink = linkInfo.Mode()&os.ModeSymlink != 0
+	entry.Excluded = directory.Excluded(filepath.Dir(path), linkInfo)
+	entry.Err = err
+
+	return entry
+}
+
+// Starts calculating the size of a directory entry in the background.
+// Excluded entries are skipped, unless they've been revealed.
+func (navigator *Navigator) calculateSize(entry *directory.Entry) {
+	if !navigator.revealed(entry) {
+		return
+	}
+
+	// Start tracking progress afresh if nothing else is being calculated.
+	if navigator.pendingCalculations == 0 {
+		navigator.scan = directory.NewScan()
+	}
+	navigator.pendingCalculations++
+
+	// Calculate the directory's size asynchronously, passing the
+	// entry so that we know where to put the result when we receive it later on.
+	go directory.Size(navigator.currentPath+"/"+entry.Name, entry, navigator.DirectorySizes,
+		navigator.abandonSizes, navigator.scan)
+	navigator.scheduleProgress()
+}
 
-	// Reset the number of pending calculations.
+// Abandons any pending size calculations and replaces the channel that
+// results are received on with one with the given buffer size, so that
+// results for entries that are no longer listed are left behind.
+func (navigator *Navigator) resetCalculations(buffer int) {
+	navigator.abandonCalculations()
+	navigator.abandonSizes = make(chan struct{})
+	navigator.DirectorySizes = make(chan *directory.EntrySize, buffer)
 	navigator.pendingCalculations = 0
+}
 
-	for index, entry := range dirEntries {
-		entryInfo, _ := os.Stat(navigator.currentPath + "/" + entry.Name())
+// Tells any pending size calculations to stop, since their
+// results won't be received. They can't be resumed.
+func (navigator *Navigator) abandonCalculations() {
+	if navigator.abandonSizes != nil {
+		close(navigator.abandonSizes)
+		navigator.abandonSizes = nil
+	}
+}
 
-		// Figure out the entry's size differently
-		// depending on whether or not it's a directory.
-		if entryInfo.IsDir() {
-			navigator.pendingCalculations++
+// Stores the results of a directory size calculation in the entry they
+// belong to, re-sorting the entries if they're being sorted automatically.
+// Provisional results are stored without flagging the size as calculated.
+func (navigator *Navigator) updateEntrySize(directorySize *directory.EntrySize) {
+	entry := directorySize.Entry
 
-			// Calculate the directory's size asynchronously, passing the current
-			// index so that we know where to put the result when we receive it later on.
-			go directory.Size(navigator.currentPath+"/"+entry.Name(), index, navigator.DirectorySizes)
-		} else {
-			size = entryInfo.Size()
-		}
+	// Ignore provisional results that arrive after the final ones.
+	if directorySize.Provisional && entry.SizeCalculated {
+		return
+	}
 
-		// Store the entry details.
-		navigator.entries[index] = &directory.Entry{Name: entry.Name(), Size: size, IsDirectory: entryInfo.IsDir(), SizeCalculated: !entryInfo.IsDir()}
+	// Update the stored entry size and flag it as calculated (or provisional).
+	entry.Size = directorySize.Size
+	entry.Items = directorySize.Items
+	entry.DiskUsage = directorySize.DiskUsage
+	entry.LastModified = directorySize.LastModified
+	entry.LastAccessed = directorySize.LastAccessed
+	if !directorySize.Provisional {
+		entry.Types = directorySize.Types
+		entry.Owners, entry.Groups = directorySize.Owners, directorySize.Groups
+	}
+	entry.Provisional = directorySize.Provisional
+	entry.SizeCalculated = !directorySize.Provisional
+
+	// Reduce this count so the view reflects the completed calculation,
+	// and stop reporting progress once there's nothing left to calculate.
+	if !directorySize.Provisional {
+		navigator.pendingCalculations--
+		navigator.scheduleProgress()
 	}
 
-	// Update the view, since we have sizes for files.
-	navigator.view <- navigator.View(view.Height())
+	// Add the directory's files to the breakdown, if one is listed.
+	if navigator.breakdownListed() {
+		if !directorySize.Provisional {
+			navigator.listBreakdown()
+		}
+		return
+	}
+
+	// Keep the entries in order if they're sorted by size, and hide
+	// directories found to have been used recently if filtering by age.
+	if !navigator.manualSort && navigator.sortOrder.Mode.DependsOnSize() {
+		navigator.sortEntries()
+	} else if navigator.minimumAge > 0 && !directorySize.Provisional {
+		navigator.arrangeEntries()
+	}
 }
 
+// Switches to the next sort mode (cycling through name, size, modification
+// time, item count, extension and last use) and sorts the entries accordingly.
 func (navigator *Navigator) SortEntries() {
-	// Sort the entries, casting them to their sortable equivalent.
-	sort.Sort(directory.SortableEntries(navigator.entries))
+	navigator.sortOrder.Mode = navigator.sortOrder.Mode.Next()
+	navigator.sortEntries()
+}
+
+// Returns the order in which the navigator's entries are sorted.
+func (navigator *Navigator) SortOrder() directory.SortOrder {
+	return navigator.sortOrder
+}
+
+// Reverses the order in which entries are sorted, and re-sorts them.
+func (navigator *Navigator) ReverseSortOrder() {
+	navigator.sortOrder.Reverse = !navigator.sortOrder.Reverse
+	navigator.sortEntries()
+}
+
+// Toggles whether directories are listed before
+// files, regardless of the sort mode, and re-sorts.
+func (navigator *Navigator) ToggleDirectoriesFirst() {
+	navigator.sortOrder.DirectoriesFirst = !navigator.sortOrder.DirectoriesFirst
+	navigator.sortEntries()
+}
+
+// Reports whether entries are re-sorted as directory sizes are calculated.
+func (navigator *Navigator) AutoSort() bool {
+	return !navigator.manualSort
+}
+
+// Toggles whether entries are re-sorted as directory sizes are calculated.
+// Enabling it re-sorts the entries immediately, to catch up on any sizes
+// that arrived while it was disabled.
+func (navigator *Navigator) ToggleAutoSort() {
+	navigator.manualSort = !navigator.manualSort
+	if !navigator.manualSort {
+		navigator.sortEntries()
+	}
+}
+
+// Sorts the entries using the active sort order, keeping
+// the selection on the same entry (rather than the same index).
+func (navigator *Navigator) sortEntries() {
+	// Trees are sorted level by level (and duplicates aren't sorted
+	// at all), so they're listed all over again.
+	if navigator.treeListed() || navigator.listing == duplicatesListing {
+		navigator.arrangeEntries()
+		return
+	}
+
+	selectedEntry := navigator.SelectedEntry()
+	navigator.sortOrder.Sort(navigator.entries)
+
+	for index, entry := range navigator.entries {
+		if entry == selectedEntry {
+			navigator.selectedIndex = index
+			break
+		}
+	}
 }
 
 // Moves the selectedIndex to the next entry in the
@@ -203,21 +528,82 @@ func (navigator *Navigator) SelectFirstEntry() {
 	navigator.selectedIndex = 0
 }
 
+// Moves the selectedIndex to the specified index,
+// clamping it to the bounds of the entry list.
+func (navigator *Navigator) SelectEntry(index int) {
+	if index >= len(navigator.entries) {
+		index = len(navigator.entries) - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	navigator.selectedIndex = index
+}
+
+// Moves the selectedIndex by offset entries (backwards, if negative),
+// stopping at the beginning or end of the list if it's reached.
+func (navigator *Navigator) SelectEntryOffset(offset int) {
+	navigator.SelectEntry(navigator.selectedIndex + offset)
+}
+
+// Moves the selectedIndex forward by half of the page size (i.e. the
+// number of rows on-screen), moving by at least one entry.
+func (navigator *Navigator) SelectNextHalfPage(pageSize int) {
+	navigator.SelectEntryOffset(halfPage(pageSize))
+}
+
+// Moves the selectedIndex backward by half of the page
+// size (i.e. the number of rows on-screen), moving by at least one entry.
+func (navigator *Navigator) SelectPreviousHalfPage(pageSize int) {
+	navigator.SelectEntryOffset(-halfPage(pageSize))
+}
+
+func halfPage(pageSize int) int {
+	if pageSize < 2 {
+		return 1
+	}
+	return pageSize / 2
+}
+
 // Navigates into the selected entry, if it is a directory.
 func (navigator *Navigator) IntoSelectedEntry() error {
 	entry := navigator.SelectedEntry()
+	if entry == nil {
+		return errNoSelection
+	}
+	if navigator.breakdownListed() {
+		return navigator.showSelectedFiles()
+	}
 	return navigator.SetWorkingDirectory(navigator.CurrentPath() + "/" + entry.Name)
 }
 
 // Removes/deletes the selected entry.
 func (navigator *Navigator) RemoveSelectedEntry() error {
-	err := os.RemoveAll(navigator.CurrentPath() + "/" + navigator.SelectedEntry().Name)
-	if err == nil {
-		if navigator.selectedIndex == len(navigator.entries)-1 {
-			navigator.selectedIndex = len(navigator.entries) - 2
+	removedEntry := navigator.SelectedEntry()
+	if removedEntry == nil {
+		return errNoSelection
+	}
+	if navigator.breakdownListed() {
+		return errBreakdownSelected
+	}
 
-			// Trim the last entry off of the slice
-			navigator.entries = navigator.entries[0 : navigator.selectedIndex+1]
+	err := remove(navigator.CurrentPath()+"/"+removedEntry.Name, removedEntry)
+	if err == nil {
+		// Drop the entry from the complete (unfiltered) set.
+		navigator.dropEntry(removedEntry)
+
+		// List trees and duplicates all over again, since the entry may have had others
+		// listed beneath it (or may have left the last copy of a duplicate on its own).
+		if navigator.treeListed() || navigator.listing == duplicatesListing {
+			index := navigator.selectedIndex
+			navigator.arrangeEntries()
+			navigator.SelectEntry(index)
+		} else if navigator.selectedIndex == len(navigator.entries)-1 {
+			// Trim the last entry off of the slice, selecting the one before it (if there is one).
+			navigator.entries = navigator.entries[0:navigator.selectedIndex]
+			if navigator.selectedIndex > 0 {
+				navigator.selectedIndex--
+			}
 		} else {
 			// Create a new slice of entries by combining slices surrounding the deleted entry.
 			navigator.entries = append(navigator.entries[0:navigator.selectedIndex],
@@ -228,6 +614,134 @@ func (navigator *Navigator) RemoveSelectedEntry() error {
 	return err
 }
 
+// Re-reads the current directory, adding and removing entries to match its
+// contents, refreshing the rest and re-calculating the sizes of its
+// directories. Directories keep their previous sizes until then.
+// If files below the directory are being listed instead (e.g. the largest
+// files), they're searched for again.
+func (navigator *Navigator) Refresh() {
+	// Search for the files being listed again, if it isn't the directory's own entries.
+	switch navigator.listing {
+	case largestFilesListing:
+		navigator.findLargestFiles()
+		return
+	case duplicatesListing:
+		navigator.findDuplicates()
+		return
+	case extensionsListing, categoriesListing, ownersListing, groupsListing:
+		navigator.readBreakdown()
+		return
+	}
+
+	for _, entry := range navigator.allEntries {
+		navigator.recordChange(entry.Name)
+	}
+
+	dirEntries, _ := ioutil.ReadDir(navigator.currentPath + "/")
+	for _, dirEntry := range dirEntries {
+		navigator.recordChange(dirEntry.Name())
+	}
+
+	navigator.applyChanges()
+}
+
+// Re-calculates the size of the selected entry (refreshing its details),
+// leaving the sizes of the other entries as they are.
+func (navigator *Navigator) RescanSelectedEntry() error {
+	entry := navigator.SelectedEntry()
+	if entry == nil {
+		return errNoSelection
+	}
+	if navigator.breakdownListed() {
+		return errBreakdownSelected
+	}
+
+	navigator.recordChange(entry.Name)
+	navigator.applyChanges()
+
+	return nil
+}
+
+// Removes an entry (and anything it contains) from the filesystem,
+// recording the removal in the session ledger and the audit log.
+// The removal doesn't begin unless it's been logged successfully.
+func remove(path string, entry *directory.Entry) error {
+	// Make sure we know how much space is being freed before it's gone.
+	calculateSizeNow(path, entry)
+
+	return audited(path, entry, audit.Removed, func() error {
+		return os.RemoveAll(path)
+	})
+}
+
+// Replaces the file at the given path with a hard link to the target file,
+// recording the space freed in the session ledger and the audit log.
+// The file isn't replaced unless it's been logged successfully.
+func link(target, path string, entry *directory.Entry) error {
+	return audited(path, entry, audit.Linked, func() error {
+		// Link using a temporary name, so that the file is replaced in one step.
+		temporary := path + ".purge-link"
+		if err := os.Link(target, temporary); err != nil {
+			return err
+		}
+		if err := os.Rename(temporary, path); err != nil {
+			os.Remove(temporary)
+			return err
+		}
+		return nil
+	})
+}
+
+// Performs an action that frees the space used by an entry, recording it in
+// the session ledger and (before and after it's performed) the audit log,
+// using the given result once it's succeeded.
+func audited(path string, entry *directory.Entry, result string, action func() error) (err error) {
+	if AuditLog != nil {
+		var record audit.Record
+		record.Path, err = filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		record.Size, record.Mode, record.Result = entry.Size, entry.Mode.String(), audit.Started
+
+		if err = AuditLog.Write(record); err != nil {
+			return errors.New("can't write to the audit log: " + err.Error())
+		}
+
+		// Log the outcome once the action has finished.
+		defer func() {
+			record.Result = result
+			if err != nil {
+				record.Result, record.Error = audit.Failed, err.Error()
+			}
+			AuditLog.Write(record)
+		}()
+	}
+
+	if err = action(); err == nil {
+		Session.Record(path, entry.Size)
+	}
+
+	return err
+}
+
+// Calculates the size of a directory entry right away, if it hasn't been already.
+func calculateSizeNow(path string, entry *directory.Entry) {
+	if entry.SizeCalculated {
+		return
+	}
+
+	// Leave the entry out, since we don't want any provisional results.
+	sizes := make(chan *directory.EntrySize, 1)
+	directory.Size(path, nil, sizes, nil, nil)
+	result := <-sizes
+
+	entry.Size, entry.Items, entry.DiskUsage = result.Size, result.Items, result.DiskUsage
+	entry.LastModified, entry.LastAccessed = result.LastModified, result.LastAccessed
+	entry.Types, entry.Owners, entry.Groups = result.Types, result.Owners, result.Groups
+	entry.SizeCalculated, entry.Provisional = true, false
+}
+
 // Navigates to the parent directory.
 func (navigator *Navigator) ToParentDirectory() error {
 	parent_path, error := filepath.Abs(navigator.CurrentPath() + "/..")
@@ -242,20 +756,71 @@ func (navigator *Navigator) View(maxRows int) *view.Buffer {
 	var start, end, size int
 	var entrySize string
 
-	// Return the current directory path as the status.
+	if navigator.helpVisible {
+		return navigator.helpView(maxRows)
+	}
+
+	// Return the current directory path as the status,
+	// or the prompt, if one is being typed into.
 	status := [2]string{navigator.CurrentPath(), ""}
+	if navigator.prompt != nil {
+		switch navigator.prompt.command {
+		case "Search":
+			status[0] = "/" + navigator.prompt.text
+		case "AgeFilter":
+			status[0] = "Older than: " + navigator.prompt.text
+		default:
+			status[0] = "Filter: " + navigator.prompt.text
+		}
+	}
 
 	// Append a percentage to the status line, if
 	// we're still calculating directory sizes.
 	if navigator.pendingCalculations > 0 {
-		entryCount := len(navigator.entries)
-		status[1] = fmt.Sprintf("(%d%%)", (entryCount-navigator.pendingCalculations)*100/entryCount)
+		status[1] = navigator.progressDescription()
 	} else {
 		avail := int64(navigator.availableBytes())
 		total := int64(navigator.totalBytes())
 		status[1] = fmt.Sprintf("%v available (%v%% used)", view.Size(avail), (total-avail)*100/total)
 	}
 
+	// Include the space freed so far this session.
+	if freed := Session.Freed(); freed > 0 {
+		status[1] += fmt.Sprintf("  %v freed", view.Size(freed))
+	}
+
+	// Note whether hidden entries are shown or, if they're being left out, how many there are.
+	if !navigator.breakdownListed() {
+		if navigator.hideHidden {
+			status[1] += fmt.Sprintf("  %d hidden", navigator.hiddenCount())
+		} else {
+			status[1] += "  hidden shown"
+		}
+	}
+
+	// Mention any excluded entries that aren't listed.
+	if excluded := navigator.hiddenExcludedCount(); excluded > 0 {
+		status[1] += fmt.Sprintf("  %d excluded", excluded)
+	}
+
+	// Prefix the status with the active sort order.
+	status[1] = navigator.sortDescription() + "  " + status[1]
+
+	// Prefix the status with the listing's description, if it isn't of the directory's entries.
+	if navigator.listing != directoryListing {
+		status[1] = navigator.listingDescription() + "  " + status[1]
+	}
+
+	// Prefix the status with the filtered entries' total size, if filtering.
+	if filters := navigator.filterDescription(); filters != "" {
+		var filteredSize int64
+		for _, entry := range navigator.entries {
+			filteredSize += entry.Size
+		}
+		status[1] = fmt.Sprintf("%v: %d matching, %v  %v", filters,
+			len(navigator.entries), view.Size(filteredSize), status[1])
+	}
+
 	// Create a slice with a size that is the lesser of the entry count and maxRows.
 	entryCount := len(navigator.Entries())
 	if maxRows > entryCount {
@@ -267,7 +832,7 @@ func (navigator *Navigator) View(maxRows int) *view.Buffer {
 
 	// Don't bother going any further if there are no entries to work with.
 	if size == 0 {
-		return &view.Buffer{Rows: viewData, Status: status}
+		return &view.Buffer{Rows: viewData, Status: status, Message: navigator.message}
 	}
 
 	// Deleting an entry can result in the cached view range indices
@@ -284,6 +849,14 @@ func (navigator *Navigator) View(maxRows int) *view.Buffer {
 		// the same range of entries to keep the view as consistent as possible.
 		start, end = navigator.viewDataIndices[0], navigator.viewDataIndices[1]
 
+		// Extend the range if entries have been added since (e.g. by expanding a directory).
+		if end-start < size {
+			end = start + size
+			if end > entryCount {
+				start, end = entryCount-size, entryCount
+			}
+		}
+
 	} else if navigator.viewDataIndices[1] != 0 && navigator.SelectedIndex() < navigator.viewDataIndices[0] {
 
 		// The selected entry is beneath the range of entries previously returned.
@@ -305,6 +878,16 @@ func (navigator *Navigator) View(maxRows int) *view.Buffer {
 		end = size
 	}
 
+	// Sum the sizes of all of the directory's entries (including those
+	// that have been filtered out, but not excluded ones), so we can
+	// show each entry's share.
+	var totalSize int64
+	for _, entry := range navigator.allEntries {
+		if !entry.Excluded {
+			totalSize += entry.Size
+		}
+	}
+
 	// Copy the navigator entries' names and
 	// formatted sizes into the slice we'll return.
 	for i, entry := range navigator.Entries()[start:end] {
@@ -312,26 +895,49 @@ func (navigator *Navigator) View(maxRows int) *view.Buffer {
 
 		// Add a trailing slash to the name
 		// if the entry is a directory.
-		var name string
+		name := navigator.displayName(entry)
 		if entry.IsDirectory {
-			name = entry.Name + "/"
-		} else {
-			name = entry.Name
+			name += "/"
 		}
 
+		// Leave the share of the total size at zero until it's known.
+		details := &view.Details{Size: entry.Size, Items: entry.Items, DiskUsage: entry.DiskUsage, ModTime: entry.ModTime,
+			LastUsed: entry.LastUsed(), Owner: directory.OwnerName(entry.Uid),
+			Group: directory.GroupName(entry.Gid), Mode: entry.Mode, Calculated: entry.SizeCalculated,
+			Provisional: entry.Provisional, Excluded: entry.Excluded}
 		if entry.SizeCalculated {
 			entrySize = view.Size(entry.Size)
+			if totalSize > 0 && !entry.Excluded {
+				details.Fraction = float64(entry.Size) / float64(totalSize)
+			}
+		} else if entry.Provisional {
+			// Partial sizes are lower bounds, which will continue to grow.
+			entrySize = view.Size(entry.Size) + "+"
 		} else {
 			entrySize = "Calculating..."
 		}
 
-		viewData[i] = view.Row{name, entrySize, highlight, entry.IsDirectory}
+		viewData[i] = view.Row{Left: name, Right: entrySize, Highlight: highlight,
+			Type: rowType(entry), Details: details}
 	}
 
 	// Store the indices used to generate the view data.
 	navigator.viewDataIndices = [2]int{start, end}
 
-	return &view.Buffer{Rows: viewData, Status: status}
+	return &view.Buffer{Rows: viewData, Status: status, Message: navigator.message}
+}
+
+// Describes the active sort order for the status line, e.g. "[size desc]".
+func (navigator *Navigator) sortDescription() string {
+	direction := "asc"
+	if navigator.sortOrder.Descending() {
+		direction = "desc"
+	}
+
+	if navigator.sortOrder.DirectoriesFirst {
+		return fmt.Sprintf("[%v %v, dirs first]", navigator.sortOrder.Mode, direction)
+	}
+	return fmt.Sprintf("[%v %v]", navigator.sortOrder.Mode, direction)
 }
 
 func (navigator *Navigator) totalBytes() uint64 {
@@ -347,3 +953,17 @@ func (navigator *Navigator) availableBytes() uint64 {
 
 	return stats.Bfree * uint64(stats.Bsize)
 }
+
+// Returns the type of row used to display the entry.
+func rowType(entry *directory.Entry) view.RowType {
+	switch {
+	case entry.Err != nil:
+		return view.ErrorRow
+	case entry.IsDirectory:
+		return view.DirectoryRow
+	case entry.IsSymlink:
+		return view.SymlinkRow
+	}
+
+	return view.FileRow
+}
@@ -1,12 +1,19 @@
 package navigator
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
+	"github.com/jmacdonald/purge/audit"
 	"github.com/jmacdonald/purge/filesystem/directory"
+	"github.com/jmacdonald/purge/input"
 	"github.com/jmacdonald/purge/view"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -31,6 +38,9 @@ var _ = Describe("Navigator", func() {
 		navigator = new(Navigator)
 		viewBuffer = make(chan<- *view.Buffer, 10)
 		navigator.view = viewBuffer
+
+		// Start each test with a fresh session.
+		Session = new(Ledger)
 	})
 
 	Describe("SetWorkingDirectory", func() {
@@ -63,6 +73,13 @@ var _ = Describe("Navigator", func() {
 				Expect(len(navigator.Entries())).To(Equal(4))
 			})
 
+			It("abandons size calculations started for the previous entries", func() {
+				abandoned := navigator.abandonSizes
+				navigator.SetWorkingDirectory(path)
+				Expect(abandoned).To(BeClosed())
+				Expect(navigator.abandonSizes).ToNot(BeClosed())
+			})
+
 			It("resets selected index to zero", func() {
 				navigator.SelectNextEntry()
 				Expect(navigator.SelectedIndex()).To(BeEquivalentTo(1))
@@ -140,6 +157,38 @@ var _ = Describe("Navigator", func() {
 			})
 		})
 
+		Context("path can't be read", func() {
+			var parentPath string
+
+			BeforeEach(func() {
+				// Permissions don't stop the superuser from reading directories.
+				if os.Geteuid() == 0 {
+					Skip("directories can always be read as root")
+				}
+
+				parentPath, _ = ioutil.TempDir("", "purge")
+				path = parentPath + "/locked"
+				os.Mkdir(path, 0000)
+
+				navigator.SelectNextEntry()
+				previousEntryCount = len(navigator.Entries())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(parentPath)
+			})
+
+			It("returns an error describing why", func() {
+				Expect(errorMessage(error)).To(Equal("Permission denied: locked"))
+			})
+
+			It("keeps the current directory and its entries", func() {
+				Expect(navigator.CurrentPath()).To(Equal(originalPath))
+				Expect(len(navigator.Entries())).To(Equal(previousEntryCount))
+				Expect(navigator.SelectedIndex()).To(BeEquivalentTo(1))
+			})
+		})
+
 		Context("path has a trailing slash", func() {
 			BeforeEach(func() {
 				path, _ = os.Getwd()
@@ -178,6 +227,154 @@ var _ = Describe("Navigator", func() {
 		})
 	})
 
+	Describe("sort order", func() {
+		entryNames := func() []string {
+			names := []string{}
+			for _, entry := range navigator.Entries() {
+				names = append(names, entry.Name)
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+		})
+
+		It("defaults to sorting by name", func() {
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByName))
+			Expect(entryNames()).To(Equal([]string{"directory", "empty_file", "file", "small_file"}))
+		})
+
+		It("cycles through sort modes", func() {
+			navigator.SortEntries()
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortBySize))
+			navigator.SortEntries()
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByModTime))
+			navigator.SortEntries()
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByItems))
+			navigator.SortEntries()
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByExtension))
+			navigator.SortEntries()
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByLastUsed))
+			navigator.SortEntries()
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByName))
+		})
+
+		It("reverses the order", func() {
+			navigator.ReverseSortOrder()
+			Expect(entryNames()).To(Equal([]string{"small_file", "file", "empty_file", "directory"}))
+		})
+
+		It("lists directories first", func() {
+			navigator.ReverseSortOrder()
+			navigator.ToggleDirectoriesFirst()
+			Expect(entryNames()).To(Equal([]string{"directory", "small_file", "file", "empty_file"}))
+		})
+
+		It("persists across directory changes", func() {
+			navigator.ReverseSortOrder()
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+			Expect(entryNames()[0]).To(Equal("small_file"))
+		})
+
+		It("keeps the selected entry selected when sorting", func() {
+			navigator.SelectEntry(2)
+			navigator.ReverseSortOrder()
+			Expect(navigator.SelectedEntry().Name).To(Equal("file"))
+			Expect(navigator.SelectedIndex()).To(Equal(1))
+		})
+
+		Describe("receiving a directory size", func() {
+			var directoryEntry *directory.Entry
+
+			BeforeEach(func() {
+				// Sort by size, and select the directory (which is listed
+				// near the end, since its size hasn't been calculated yet).
+				navigator.SortEntries()
+				for navigator.SelectedEntry().Name != "directory" {
+					navigator.SelectNextEntry()
+				}
+				directoryEntry = navigator.SelectedEntry()
+			})
+
+			JustBeforeEach(func() {
+				navigator.updateEntrySize(&directory.EntrySize{Entry: directoryEntry, Size: 1 << 20, Items: 3})
+			})
+
+			It("updates the entry it belongs to", func() {
+				Expect(directoryEntry.Size).To(BeEquivalentTo(1 << 20))
+				Expect(directoryEntry.Items).To(BeEquivalentTo(3))
+				Expect(directoryEntry.SizeCalculated).To(BeTrue())
+			})
+
+			Context("auto-sorting is enabled", func() {
+				It("re-sorts the entries", func() {
+					Expect(entryNames()[0]).To(Equal("directory"))
+				})
+
+				It("keeps the entry selected", func() {
+					Expect(navigator.SelectedEntry()).To(BeIdenticalTo(directoryEntry))
+				})
+			})
+
+			Context("auto-sorting is disabled", func() {
+				BeforeEach(func() {
+					navigator.ToggleAutoSort()
+				})
+
+				It("does not re-sort the entries", func() {
+					Expect(entryNames()).To(Equal([]string{"file", "small_file", "directory", "empty_file"}))
+				})
+
+				It("re-sorts the entries once it is re-enabled", func() {
+					navigator.ToggleAutoSort()
+					Expect(navigator.AutoSort()).To(BeTrue())
+					Expect(entryNames()[0]).To(Equal("directory"))
+				})
+			})
+		})
+
+		Describe("receiving a provisional directory size", func() {
+			var directoryEntry *directory.Entry
+			var pending int
+
+			BeforeEach(func() {
+				navigator.SortEntries()
+				for navigator.SelectedEntry().Name != "directory" {
+					navigator.SelectNextEntry()
+				}
+				directoryEntry = navigator.SelectedEntry()
+				pending = navigator.pendingCalculations
+
+				navigator.updateEntrySize(&directory.EntrySize{Entry: directoryEntry, Size: 1 << 20, Provisional: true})
+			})
+
+			It("stores the partial size without flagging it as calculated", func() {
+				Expect(directoryEntry.Size).To(BeEquivalentTo(1 << 20))
+				Expect(directoryEntry.Provisional).To(BeTrue())
+				Expect(directoryEntry.SizeCalculated).To(BeFalse())
+				Expect(navigator.pendingCalculations).To(Equal(pending))
+			})
+
+			It("re-sorts the entries using the partial size", func() {
+				Expect(entryNames()[0]).To(Equal("directory"))
+			})
+
+			It("displays the partial size as a lower bound", func() {
+				row := navigator.View(10).Rows[0]
+				Expect(row.Right).To(Equal("1.0 MB+"))
+				Expect(row.Details.Provisional).To(BeTrue())
+			})
+
+			It("ignores provisional sizes that arrive after the final size", func() {
+				navigator.updateEntrySize(&directory.EntrySize{Entry: directoryEntry, Size: 2 << 20})
+				navigator.updateEntrySize(&directory.EntrySize{Entry: directoryEntry, Size: 1 << 20, Provisional: true})
+				Expect(directoryEntry.Size).To(BeEquivalentTo(2 << 20))
+				Expect(directoryEntry.Provisional).To(BeFalse())
+			})
+		})
+	})
+
 	Describe("SelectedEntry", func() {
 		BeforeEach(func() {
 			navigator.SetWorkingDirectory(originalPath)
@@ -370,445 +567,1946 @@ var _ = Describe("Navigator", func() {
 		})
 	})
 
-	Describe("IntoSelectedEntry", func() {
-		JustBeforeEach(func() {
-			error = navigator.IntoSelectedEntry()
+	Describe("SelectEntry", func() {
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
 		})
 
-		BeforeEach(func() {
-			path, _ = os.Getwd()
-			path += "/sample"
-			navigator.SetWorkingDirectory(path)
+		It("selects the entry at the specified index", func() {
+			navigator.SelectEntry(2)
+			Expect(navigator.SelectedIndex()).To(Equal(2))
 		})
 
-		Context("a directory is selected", func() {
+		It("selects the last entry when the index is out of bounds", func() {
+			navigator.SelectEntry(100)
+			Expect(navigator.SelectedIndex()).To(Equal(len(navigator.Entries()) - 1))
+		})
+
+		It("selects the first entry when the index is negative", func() {
+			navigator.SelectEntry(-3)
+			Expect(navigator.SelectedIndex()).To(BeZero())
+		})
+
+		Context("directory has never been set", func() {
 			BeforeEach(func() {
-				for navigator.SelectedEntry().Name != "directory" {
-					navigator.SelectNextEntry()
-				}
+				navigator = new(Navigator)
 			})
 
-			It("navigates into the selected entry", func() {
-				Expect(navigator.CurrentPath()).To(BeEquivalentTo(path + "/directory"))
+			It("does not change the selected index", func() {
+				navigator.SelectEntry(2)
+				Expect(navigator.SelectedIndex()).To(BeZero())
 			})
+		})
+	})
 
-			It("does not return an error", func() {
-				Expect(error).To(BeNil())
-			})
+	Describe("SelectEntryOffset", func() {
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+			navigator.SelectEntry(1)
 		})
 
-		Context("a file is selected", func() {
-			BeforeEach(func() {
-				for navigator.SelectedEntry().Name != "file" {
-					navigator.SelectNextEntry()
-				}
-			})
+		It("moves the selected index forward by a positive offset", func() {
+			navigator.SelectEntryOffset(2)
+			Expect(navigator.SelectedIndex()).To(Equal(3))
+		})
 
-			It("does not change the working directory", func() {
-				Expect(navigator.CurrentPath()).To(BeEquivalentTo(path))
-			})
+		It("moves the selected index backward by a negative offset", func() {
+			navigator.SelectEntryOffset(-1)
+			Expect(navigator.SelectedIndex()).To(BeZero())
+		})
 
-			It("returns an error", func() {
-				Expect(error).ToNot(BeNil())
-			})
+		It("stops at the last entry", func() {
+			navigator.SelectEntryOffset(10)
+			Expect(navigator.SelectedIndex()).To(Equal(len(navigator.Entries()) - 1))
+		})
+
+		It("stops at the first entry", func() {
+			navigator.SelectEntryOffset(-10)
+			Expect(navigator.SelectedIndex()).To(BeZero())
 		})
 	})
 
-	Describe("RemoveSelectedEntry", func() {
-		var file_name, directory_name string
-		var entryCount int
+	Describe("SelectNextHalfPage", func() {
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+		})
 
+		It("moves the selected index forward by half of the page size", func() {
+			navigator.SelectNextHalfPage(4)
+			Expect(navigator.SelectedIndex()).To(Equal(2))
+		})
+
+		It("moves the selected index by at least one entry", func() {
+			navigator.SelectNextHalfPage(1)
+			Expect(navigator.SelectedIndex()).To(Equal(1))
+		})
+	})
+
+	Describe("SelectPreviousHalfPage", func() {
 		BeforeEach(func() {
-			navigator.SetWorkingDirectory(originalPath)
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+			navigator.SelectLastEntry()
 		})
 
-		JustBeforeEach(func() {
-			error = navigator.RemoveSelectedEntry()
+		It("moves the selected index backward by half of the page size", func() {
+			navigator.SelectPreviousHalfPage(4)
+			Expect(navigator.SelectedIndex()).To(Equal(1))
 		})
 
-		Context("selected entry is a file", func() {
-			BeforeEach(func() {
-				file_name = "new_file"
-				os.Create(file_name)
+		It("moves the selected index by at least one entry", func() {
+			navigator.SelectPreviousHalfPage(0)
+			Expect(navigator.SelectedIndex()).To(Equal(2))
+		})
+	})
 
-				// Update the navigator's cached entries.
-				navigator.SetWorkingDirectory(originalPath)
+	Describe("Search", func() {
+		var found bool
+		var term string
 
-				// Keep a reference to the size of the original entry set.
-				entryCount = len(navigator.Entries())
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+		})
 
-				// Select the newly created file.
-				for navigator.SelectedEntry().Name != file_name {
-					navigator.SelectNextEntry()
-				}
-			})
+		JustBeforeEach(func() {
+			found = navigator.Search(term)
+		})
 
-			It("deletes the file", func() {
-				_, err := os.Stat(file_name)
-				Expect(os.IsNotExist(err)).To(BeTrue())
+		Context("an entry matches the term", func() {
+			BeforeEach(func() {
+				term = "SMALL"
 			})
 
-			It("removes the file from the navigator's entries", func() {
-				file_entry := &directory.Entry{Name: file_name, Size: 0, IsDirectory: false}
-				Expect(navigator.Entries()).ToNot(ContainElement(file_entry))
+			It("selects the matching entry, ignoring case", func() {
+				Expect(navigator.SelectedEntry().Name).To(Equal("small_file"))
 			})
 
-			It("leaves the navigator with the correct number of entries", func() {
-				Expect(len(navigator.Entries())).To(Equal(entryCount - 1))
+			It("returns true", func() {
+				Expect(found).To(BeTrue())
 			})
 		})
 
-		Context("selected entry is a directory with files", func() {
+		Context("no entries match the term", func() {
 			BeforeEach(func() {
-				file_name = "new_file"
-				directory_name = "new_directory"
-				os.Mkdir(directory_name, 0700)
-				os.Create(directory_name + "/" + file_name)
-
-				// Update the navigator's cached entries.
-				navigator.SetWorkingDirectory(originalPath)
+				term = "missing"
+				navigator.SelectNextEntry()
+			})
 
-				for navigator.SelectedEntry().Name != directory_name {
-					navigator.SelectNextEntry()
-				}
+			It("does not change the selected index", func() {
+				Expect(navigator.SelectedIndex()).To(Equal(1))
 			})
 
-			It("deletes the directory", func() {
-				_, err := os.Stat(directory_name)
-				Expect(os.IsNotExist(err)).To(BeTrue())
+			It("returns false", func() {
+				Expect(found).To(BeFalse())
 			})
 		})
 
-		Describe("selected entry after removal", func() {
-			var first_file_name, second_file_name, last_file_name string
+		Describe("SelectNextMatch", func() {
 			BeforeEach(func() {
-				// Create a directory.
-				directory_name = "new_directory"
-				os.Mkdir(directory_name, 0700)
-
-				// Create three files in that directory, using numbers to guarantee sorting.
-				first_file_name = "1"
-				second_file_name = "2"
-				last_file_name = "3"
-				os.Create(directory_name + "/" + first_file_name)
-				os.Create(directory_name + "/" + second_file_name)
-				os.Create(directory_name + "/" + last_file_name)
-
-				// Navigate into the new directory
-				navigator.SetWorkingDirectory(originalPath + "/" + directory_name)
+				term = "file"
 			})
 
-			AfterEach(func() {
-				os.RemoveAll(directory_name)
+			It("selects the next matching entry", func() {
+				navigator.SelectNextMatch()
+				Expect(navigator.SelectedEntry().Name).To(Equal("file"))
 			})
 
-			Context("selected entry is the first entry", func() {
-				It("selects the second entry", func() {
-					Expect(navigator.SelectedEntry().Name).To(Equal(second_file_name))
-				})
+			It("wraps around to the first matching entry", func() {
+				navigator.SelectNextMatch()
+				navigator.SelectNextMatch()
+				navigator.SelectNextMatch()
+				Expect(navigator.SelectedEntry().Name).To(Equal("empty_file"))
 			})
+		})
 
-			Context("selected entry is the second entry", func() {
-				BeforeEach(func() {
-					navigator.SelectNextEntry()
-				})
-
-				It("selects the second entry", func() {
-					Expect(navigator.SelectedEntry().Name).To(Equal(last_file_name))
-				})
+		Describe("SelectPreviousMatch", func() {
+			BeforeEach(func() {
+				term = "file"
 			})
 
-			Context("selected entry is the last entry", func() {
-				BeforeEach(func() {
-					navigator.SelectNextEntry()
-					navigator.SelectNextEntry()
-				})
-
-				It("selects the second entry", func() {
-					Expect(navigator.SelectedEntry().Name).To(Equal(second_file_name))
-				})
+			It("wraps around to the last matching entry", func() {
+				navigator.SelectPreviousMatch()
+				Expect(navigator.SelectedEntry().Name).To(Equal("small_file"))
 			})
 		})
 	})
 
-	Describe("ToParentDirectory", func() {
-		var parent_path string
+	Describe("SetFilter", func() {
+		var pattern string
 
 		BeforeEach(func() {
-			navigator.SetWorkingDirectory(originalPath)
+			navigator.SetWorkingDirectory(originalPath + "/sample")
 		})
 
 		JustBeforeEach(func() {
-			error = navigator.ToParentDirectory()
+			error = navigator.SetFilter(pattern)
 		})
 
-		Context("directory has a parent directory", func() {
+		entryNames := func() []string {
+			names := []string{}
+			for _, entry := range navigator.Entries() {
+				names = append(names, entry.Name)
+			}
+			return names
+		}
+
+		Context("pattern is a glob", func() {
 			BeforeEach(func() {
-				path, _ = os.Getwd()
-				parent_path = path + "/sample"
-				path += "/sample/directory"
-				navigator.SetWorkingDirectory(path)
+				pattern = "*_file"
 			})
 
-			It("navigates to the parent directory", func() {
-				Expect(navigator.CurrentPath()).To(BeEquivalentTo(parent_path))
+			It("restricts entries to those matching the glob", func() {
+				Expect(entryNames()).To(Equal([]string{"empty_file", "small_file"}))
 			})
-		})
+
+			It("stores the pattern", func() {
+				Expect(navigator.Filter()).To(Equal(pattern))
+			})
+		})
+
+		Context("pattern has no wildcards", func() {
+			BeforeEach(func() {
+				pattern = "all"
+			})
+
+			It("restricts entries to those containing the pattern", func() {
+				Expect(entryNames()).To(Equal([]string{"small_file"}))
+			})
+		})
+
+		Context("pattern is a regular expression", func() {
+			BeforeEach(func() {
+				pattern = "/^(file|directory)$/"
+			})
+
+			It("restricts entries to those matching the expression", func() {
+				Expect(entryNames()).To(Equal([]string{"directory", "file"}))
+			})
+		})
+
+		Context("pattern is an invalid regular expression", func() {
+			BeforeEach(func() {
+				pattern = "/(/"
+			})
+
+			It("returns an error", func() {
+				Expect(error).ToNot(BeNil())
+			})
+
+			It("does not filter the entries", func() {
+				Expect(len(navigator.Entries())).To(Equal(4))
+			})
+		})
+
+		Context("the selected entry matches the pattern", func() {
+			BeforeEach(func() {
+				pattern = "*_file"
+				for navigator.SelectedEntry().Name != "small_file" {
+					navigator.SelectNextEntry()
+				}
+			})
+
+			It("keeps the entry selected", func() {
+				Expect(navigator.SelectedEntry().Name).To(Equal("small_file"))
+			})
+		})
+
+		Context("pattern is empty after filtering", func() {
+			BeforeEach(func() {
+				navigator.SetFilter("*_file")
+				pattern = ""
+			})
+
+			It("restores all of the entries", func() {
+				Expect(len(navigator.Entries())).To(Equal(4))
+			})
+		})
+
+		Context("the working directory changes", func() {
+			BeforeEach(func() {
+				pattern = "*_file"
+			})
+
+			It("removes the filter", func() {
+				navigator.SetWorkingDirectory(originalPath + "/sample")
+				Expect(navigator.Filter()).To(BeEmpty())
+				Expect(len(navigator.Entries())).To(Equal(4))
+			})
+		})
+	})
+
+	Describe("SetAgeFilter", func() {
+		var directoryPath string
+		old := time.Now().Add(-200 * 24 * time.Hour)
+
+		entryNames := func() []string {
+			names := []string{}
+			for _, entry := range navigator.Entries() {
+				names = append(names, entry.Name)
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(directoryPath+"/stale", 0755)
+			os.Mkdir(directoryPath+"/active", 0755)
+			ioutil.WriteFile(directoryPath+"/stale/file", []byte("stale"), 0644)
+			ioutil.WriteFile(directoryPath+"/active/file", []byte("active"), 0644)
+			ioutil.WriteFile(directoryPath+"/recent", []byte("recent"), 0644)
+			ioutil.WriteFile(directoryPath+"/old", []byte("old"), 0644)
+			os.Chtimes(directoryPath+"/stale/file", old, old)
+			os.Chtimes(directoryPath+"/old", old, old)
+			for _, name := range []string{"stale", "active"} {
+				os.Chtimes(directoryPath+"/"+name, old, old)
+			}
+
+			navigator.SetWorkingDirectory(directoryPath)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
+
+		It("hides entries used within the age once their sizes are known", func(done Done) {
+			Expect(navigator.SetAgeFilter("180d")).To(Succeed())
+			Expect(entryNames()).To(Equal([]string{"active", "old", "stale"}))
+
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+			Expect(entryNames()).To(Equal([]string{"old", "stale"}))
+			close(done)
+		})
+
+		It("describes the filter in the status line", func() {
+			navigator.SetAgeFilter("180d")
+			Expect(navigator.AgeFilter()).To(Equal("180d"))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("older than 180d: 3 matching"))
+		})
+
+		It("persists across directory changes", func() {
+			navigator.SetAgeFilter("180d")
+			navigator.SetWorkingDirectory(directoryPath)
+			Expect(navigator.AgeFilter()).To(Equal("180d"))
+			Expect(entryNames()).To(Equal([]string{"active", "old", "stale"}))
+		})
+
+		It("restores all of the entries when empty", func() {
+			navigator.SetAgeFilter("180d")
+			Expect(navigator.SetAgeFilter("")).To(Succeed())
+			Expect(entryNames()).To(HaveLen(4))
+		})
+
+		It("returns an error for an invalid age, leaving the entries alone", func() {
+			Expect(navigator.SetAgeFilter("soon")).ToNot(Succeed())
+			Expect(navigator.AgeFilter()).To(BeEmpty())
+			Expect(entryNames()).To(HaveLen(4))
+		})
+	})
+
+	Describe("ParseAge", func() {
+		It("parses days, weeks, months and years", func() {
+			Expect(ParseAge("180d")).To(Equal(180 * 24 * time.Hour))
+			Expect(ParseAge("2w")).To(Equal(14 * 24 * time.Hour))
+			Expect(ParseAge("6mo")).To(Equal(180 * 24 * time.Hour))
+			Expect(ParseAge("1y")).To(Equal(365 * 24 * time.Hour))
+		})
+
+		It("parses durations", func() {
+			Expect(ParseAge("12h")).To(Equal(12 * time.Hour))
+			Expect(ParseAge("30m")).To(Equal(30 * time.Minute))
+		})
+
+		It("rejects invalid and negative ages", func() {
+			_, err := ParseAge("d")
+			Expect(err).ToNot(BeNil())
+			_, err = ParseAge("-1d")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("IntoSelectedEntry", func() {
+		JustBeforeEach(func() {
+			error = navigator.IntoSelectedEntry()
+		})
+
+		BeforeEach(func() {
+			path, _ = os.Getwd()
+			path += "/sample"
+			navigator.SetWorkingDirectory(path)
+		})
+
+		Context("a directory is selected", func() {
+			BeforeEach(func() {
+				for navigator.SelectedEntry().Name != "directory" {
+					navigator.SelectNextEntry()
+				}
+			})
+
+			It("navigates into the selected entry", func() {
+				Expect(navigator.CurrentPath()).To(BeEquivalentTo(path + "/directory"))
+			})
+
+			It("does not return an error", func() {
+				Expect(error).To(BeNil())
+			})
+		})
+
+		Context("a file is selected", func() {
+			BeforeEach(func() {
+				for navigator.SelectedEntry().Name != "file" {
+					navigator.SelectNextEntry()
+				}
+			})
+
+			It("does not change the working directory", func() {
+				Expect(navigator.CurrentPath()).To(BeEquivalentTo(path))
+			})
+
+			It("returns an error", func() {
+				Expect(error).ToNot(BeNil())
+			})
+		})
+	})
+
+	Describe("RemoveSelectedEntry", func() {
+		var file_name, directory_name string
+		var entryCount int
+
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath)
+		})
+
+		JustBeforeEach(func() {
+			error = navigator.RemoveSelectedEntry()
+		})
+
+		Context("selected entry is a file", func() {
+			BeforeEach(func() {
+				file_name = "new_file"
+				os.Create(file_name)
+
+				// Update the navigator's cached entries.
+				navigator.SetWorkingDirectory(originalPath)
+
+				// Keep a reference to the size of the original entry set.
+				entryCount = len(navigator.Entries())
+
+				// Select the newly created file.
+				for navigator.SelectedEntry().Name != file_name {
+					navigator.SelectNextEntry()
+				}
+			})
+
+			It("deletes the file", func() {
+				_, err := os.Stat(file_name)
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+
+			It("removes the file from the navigator's entries", func() {
+				file_entry := &directory.Entry{Name: file_name, Size: 0, IsDirectory: false}
+				Expect(navigator.Entries()).ToNot(ContainElement(file_entry))
+			})
+
+			It("leaves the navigator with the correct number of entries", func() {
+				Expect(len(navigator.Entries())).To(Equal(entryCount - 1))
+			})
+		})
+
+		Context("selected entry is a directory with files", func() {
+			BeforeEach(func() {
+				file_name = "new_file"
+				directory_name = "new_directory"
+				os.Mkdir(directory_name, 0700)
+				os.Create(directory_name + "/" + file_name)
+
+				// Update the navigator's cached entries.
+				navigator.SetWorkingDirectory(originalPath)
+
+				for navigator.SelectedEntry().Name != directory_name {
+					navigator.SelectNextEntry()
+				}
+			})
+
+			It("deletes the directory", func() {
+				_, err := os.Stat(directory_name)
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		Describe("selected entry after removal", func() {
+			var first_file_name, second_file_name, last_file_name string
+			BeforeEach(func() {
+				// Create a directory.
+				directory_name = "new_directory"
+				os.Mkdir(directory_name, 0700)
+
+				// Create three files in that directory, using numbers to guarantee sorting.
+				first_file_name = "1"
+				second_file_name = "2"
+				last_file_name = "3"
+				os.Create(directory_name + "/" + first_file_name)
+				os.Create(directory_name + "/" + second_file_name)
+				os.Create(directory_name + "/" + last_file_name)
+
+				// Navigate into the new directory
+				navigator.SetWorkingDirectory(originalPath + "/" + directory_name)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(directory_name)
+			})
+
+			Context("selected entry is the first entry", func() {
+				It("selects the second entry", func() {
+					Expect(navigator.SelectedEntry().Name).To(Equal(second_file_name))
+				})
+			})
+
+			Context("selected entry is the second entry", func() {
+				BeforeEach(func() {
+					navigator.SelectNextEntry()
+				})
+
+				It("selects the second entry", func() {
+					Expect(navigator.SelectedEntry().Name).To(Equal(last_file_name))
+				})
+			})
+
+			Context("selected entry is the last entry", func() {
+				BeforeEach(func() {
+					navigator.SelectNextEntry()
+					navigator.SelectNextEntry()
+				})
+
+				It("selects the second entry", func() {
+					Expect(navigator.SelectedEntry().Name).To(Equal(second_file_name))
+				})
+			})
+		})
+
+		Context("selected entry is the only entry", func() {
+			BeforeEach(func() {
+				directory_name = "new_directory"
+				os.Mkdir(directory_name, 0700)
+				os.Create(directory_name + "/only_file")
+				navigator.SetWorkingDirectory(originalPath + "/" + directory_name)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(directory_name)
+			})
+
+			It("leaves nothing selected", func() {
+				Expect(navigator.Entries()).To(BeEmpty())
+				Expect(navigator.SelectedIndex()).To(BeZero())
+				Expect(navigator.SelectedEntry()).To(BeNil())
+			})
+
+			It("applies the change reported by the watcher for it", func() {
+				navigator.recordChange("only_file")
+				Expect(navigator.applyChanges).ToNot(Panic())
+				Expect(navigator.SelectedEntry()).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Ledger", func() {
+		var ledger *Ledger
+
+		BeforeEach(func() {
+			ledger = new(Ledger)
+		})
+
+		It("totals the space freed", func() {
+			ledger.Record("/tmp/a", 1024)
+			ledger.Record("/tmp/b", 2048)
+			Expect(ledger.Freed()).To(Equal(int64(3072)))
+			Expect(ledger.Removals()[1].Path).To(Equal("/tmp/b"))
+		})
+
+		It("summarizes each removal", func() {
+			ledger.Record("/tmp/a", 1024)
+			Expect(ledger.Summary()).To(MatchRegexp(`^Freed 1.0 KB by removing 1 entry:\n  \d\d:\d\d:\d\d  1.0 KB  /tmp/a\n$`))
+		})
+
+		It("has nothing to summarize if nothing was removed", func() {
+			Expect(ledger.Summary()).To(BeEmpty())
+		})
+
+		Context("an entry is removed", func() {
+			BeforeEach(func() {
+				os.Mkdir("new_directory", 0700)
+				ioutil.WriteFile("new_directory/new_file", []byte("purge!"), 0600)
+				navigator.SetWorkingDirectory(originalPath)
+				for navigator.SelectedEntry().Name != "new_directory" {
+					navigator.SelectNextEntry()
+				}
+
+				navigator.RemoveSelectedEntry()
+			})
+
+			It("records the removal in the session", func() {
+				Expect(Session.Removals()).To(HaveLen(1))
+				Expect(Session.Removals()[0].Path).To(Equal(originalPath + "/new_directory"))
+				Expect(Session.Freed()).To(Equal(int64(6)))
+			})
+
+			It("shows the space freed in the status line", func() {
+				Expect(navigator.View(1).Status[1]).To(ContainSubstring("6 bytes freed"))
+			})
+		})
+	})
+
+	Describe("audit log", func() {
+		BeforeEach(func() {
+			AuditLog, _ = audit.Open("audit/audit.log")
+			ioutil.WriteFile("new_file", []byte("purge!"), 0600)
+			navigator.SetWorkingDirectory(originalPath)
+			for navigator.SelectedEntry().Name != "new_file" {
+				navigator.SelectNextEntry()
+			}
+
+			navigator.RemoveSelectedEntry()
+		})
+
+		AfterEach(func() {
+			AuditLog.Close()
+			AuditLog = nil
+			os.RemoveAll("audit")
+		})
+
+		It("logs removals before and after they happen", func() {
+			data, _ := ioutil.ReadFile("audit/audit.log")
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines).To(HaveLen(2))
+
+			var records [2]audit.Record
+			json.Unmarshal([]byte(lines[0]), &records[0])
+			json.Unmarshal([]byte(lines[1]), &records[1])
+			Expect(records[0].Result).To(Equal(audit.Started))
+			Expect(records[1].Result).To(Equal(audit.Removed))
+			Expect(records[1].Path).To(Equal(originalPath + "/new_file"))
+			Expect(records[1].Size).To(Equal(int64(6)))
+			Expect(records[1].Mode).To(Equal("-rw-------"))
+		})
+	})
+
+	Describe("messages", func() {
+		Describe("removeSelectedEntry", func() {
+			Context("removal succeeds", func() {
+				BeforeEach(func() {
+					ioutil.WriteFile("new_file", []byte("purge!"), 0600)
+					navigator.SetWorkingDirectory(originalPath)
+					for navigator.SelectedEntry().Name != "new_file" {
+						navigator.SelectNextEntry()
+					}
+
+					navigator.removeSelectedEntry()
+				})
+
+				AfterEach(func() {
+					os.Remove("new_file")
+				})
+
+				It("reports the space freed", func() {
+					Expect(navigator.Message()).To(Equal(&view.Message{Text: "Deleted new_file (6 bytes freed)", Severity: view.Info}))
+				})
+			})
+
+			Context("removal fails", func() {
+				BeforeEach(func() {
+					os.Mkdir("empty_directory", 0700)
+					navigator.SetWorkingDirectory(originalPath + "/empty_directory")
+
+					navigator.removeSelectedEntry()
+				})
+
+				AfterEach(func() {
+					os.Remove("empty_directory")
+				})
+
+				It("reports the error", func() {
+					Expect(navigator.Message()).To(Equal(&view.Message{Text: "No entry selected", Severity: view.Failure}))
+				})
+			})
+		})
+
+		Describe("errorMessage", func() {
+			It("names the file an error relates to", func() {
+				err := &os.PathError{Op: "open", Path: "/tmp/bar", Err: syscall.EACCES}
+				Expect(errorMessage(err)).To(Equal("Permission denied: bar"))
+			})
+
+			It("capitalizes other errors", func() {
+				Expect(errorMessage(fmt.Errorf("path is not a directory"))).To(Equal("Path is not a directory"))
+			})
+		})
+
+		It("includes the message in the view", func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+			navigator.notify(view.Failure, "Careful")
+			Expect(navigator.View(10).Message).To(Equal(&view.Message{Text: "Careful", Severity: view.Failure}))
+
+			navigator.clearMessage()
+			Expect(navigator.View(10).Message).To(BeNil())
+		})
+	})
+
+	Describe("ToParentDirectory", func() {
+		var parent_path string
+
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath)
+		})
+
+		JustBeforeEach(func() {
+			error = navigator.ToParentDirectory()
+		})
+
+		Context("directory has a parent directory", func() {
+			BeforeEach(func() {
+				path, _ = os.Getwd()
+				parent_path = path + "/sample"
+				path += "/sample/directory"
+				navigator.SetWorkingDirectory(path)
+			})
+
+			It("navigates to the parent directory", func() {
+				Expect(navigator.CurrentPath()).To(BeEquivalentTo(parent_path))
+			})
+		})
+	})
+
+	Describe("View", func() {
+		var buffer *view.Buffer
+		var maxRows int
+
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath)
+		})
+
+		JustBeforeEach(func() {
+			buffer = navigator.View(maxRows)
+		})
+
+		Describe("Status Line", func() {
+			Context("when calculations are underway", func() {
+				BeforeEach(func() {
+					navigator.pendingCalculations = 1
+					navigator.scan = directory.NewScan()
+					sizes := make(chan *directory.EntrySize, 1)
+					directory.Size(navigator.CurrentPath()+"/sample/directory", nil, sizes, nil, navigator.scan)
+				})
+
+				It("reports the files and bytes scanned, and the subtree being walked", func() {
+					Expect(buffer.Status[1]).To(MatchRegexp(`Scanning sample/directory: 1 file, 250.0 KB at .+/s  hidden shown$`))
+				})
+			})
+
+			Context("when all calculations have completed", func() {
+				BeforeEach(func() {
+					// FIXME: This calculation gets stuck at 75%.
+					// Hijack the navigator to simulate calculation completion.
+					navigator.pendingCalculations = 0
+				})
+
+				It("returns the current directory path as its first element", func() {
+					Expect(buffer.Status[0]).To(Equal(navigator.CurrentPath()))
+				})
+
+				It("returns disk/partition space statistics as its second element", func() {
+					avail := int64(navigator.availableBytes())
+					total := int64(navigator.totalBytes())
+					status := fmt.Sprintf("%v available (%v%% used)", view.Size(avail), (total-avail)*100/total)
+
+					Expect(buffer.Status[1]).To(HaveSuffix(status + "  hidden shown"))
+				})
+
+				It("includes the sort order in its second element", func() {
+					Expect(buffer.Status[1]).To(HavePrefix("[name asc]"))
+				})
+			})
+		})
+		Context("maxRows is set to 1", func() {
+			BeforeEach(func() {
+				maxRows = 1
+			})
+
+			It("returns a buffer with the right number of rows", func() {
+				Expect(len(buffer.Rows)).To(BeEquivalentTo(maxRows))
+			})
+
+			It("stores the proper view data indices", func() {
+				Expect(navigator.ViewDataIndices()).To(Equal([2]int{0, 1}))
+			})
+
+			Describe("returned row", func() {
+				It("has its left value set to the first entry's name", func() {
+					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[0].Name))
+				})
+
+				It("has its right value set to the first entry's formatted size", func() {
+					formattedSize := view.Size(navigator.Entries()[0].Size)
+					Expect(buffer.Rows[0].Right).To(Equal(formattedSize))
+				})
+
+				It("has details with the first entry's share of the directory's total size", func() {
+					var totalSize int64
+					for _, entry := range navigator.Entries() {
+						totalSize += entry.Size
+					}
+					fraction := float64(navigator.Entries()[0].Size) / float64(totalSize)
+
+					Expect(buffer.Rows[0].Details.Fraction).To(BeNumerically("~", fraction))
+				})
+
+				It("has details describing the first entry", func() {
+					entry := navigator.Entries()[0]
+					Expect(buffer.Rows[0].Details.ModTime).To(Equal(entry.ModTime))
+					Expect(buffer.Rows[0].Details.Mode).To(Equal(entry.Mode))
+					Expect(buffer.Rows[0].Details.Owner).To(Equal(directory.OwnerName(entry.Uid)))
+					Expect(buffer.Rows[0].Details.Group).To(Equal(directory.GroupName(entry.Gid)))
+				})
+
+				It("has its highlight value set to the first entry's highlighted status", func() {
+					Expect(buffer.Rows[0].Highlight).To(BeTrue())
+				})
+
+				Context("selected entry is a directory", func() {
+					BeforeEach(func() {
+						entry := navigator.SelectedEntry()
+
+						for !entry.IsDirectory {
+							navigator.SelectNextEntry()
+							entry = navigator.SelectedEntry()
+						}
+					})
+
+					It("has its type set to directory", func() {
+						Expect(buffer.Rows[0].Type).To(Equal(view.DirectoryRow))
+					})
+
+					It("has a forward slash appended to its name", func() {
+						Expect(buffer.Rows[0].Left).To(Equal(navigator.SelectedEntry().Name + "/"))
+					})
+				})
+
+				Context("selected entry is not a directory", func() {
+					BeforeEach(func() {
+						entry := navigator.SelectedEntry()
+
+						for entry.IsDirectory {
+							navigator.SelectNextEntry()
+							entry = navigator.SelectedEntry()
+						}
+					})
+
+					It("has its type set to file", func() {
+						Expect(buffer.Rows[0].Type).To(Equal(view.FileRow))
+					})
+				})
+			})
+		})
+
+		Context("maxRows is set to 2", func() {
+			BeforeEach(func() {
+				maxRows = 2
+			})
+
+			It("returns a buffer with the right number of rows", func() {
+				Expect(len(buffer.Rows)).To(BeEquivalentTo(maxRows))
+			})
+
+			It("stores the proper view data indices", func() {
+				Expect(navigator.ViewDataIndices()).To(Equal([2]int{0, 2}))
+			})
+
+			Context("selected entry has never been changed", func() {
+				It("returns the first and second rows", func() {
+					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[0].Name))
+					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[1].Name))
+				})
+
+				It("sets the first row as highlighted", func() {
+					Expect(buffer.Rows[0].Highlight).To(BeTrue())
+				})
+			})
+
+			Context("the second entry is selected", func() {
+				BeforeEach(func() {
+					navigator.SelectNextEntry()
+				})
+
+				It("returns the first row", func() {
+					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[0].Name))
+				})
+
+				It("returns the second row", func() {
+					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[1].Name))
+				})
+
+				It("sets the second row as highlighted", func() {
+					Expect(buffer.Rows[1].Highlight).To(BeTrue())
+				})
+			})
+
+			Context("the second entry is selected, the view is rendered, and then the third entry is selected", func() {
+				BeforeEach(func() {
+					navigator.SelectNextEntry()
+					_ = navigator.View(maxRows)
+					navigator.SelectNextEntry()
+				})
+
+				It("returns the second row", func() {
+					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[1].Name))
+				})
+
+				It("returns the third row", func() {
+					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[2].Name))
+				})
+
+				It("sets the third row as highlighted", func() {
+					Expect(buffer.Rows[1].Highlight).To(BeTrue())
+				})
+			})
+
+			Context("the third entry is selected, the view is rendered, and then the second entry is selected", func() {
+				BeforeEach(func() {
+					navigator.SelectNextEntry()
+					navigator.SelectNextEntry()
+					_ = navigator.View(maxRows)
+					navigator.SelectPreviousEntry()
+				})
+
+				It("returns the second row", func() {
+					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[1].Name))
+				})
+
+				It("returns the third row", func() {
+					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[2].Name))
+				})
+
+				It("sets the second row as highlighted", func() {
+					Expect(buffer.Rows[0].Highlight).To(BeTrue())
+				})
+			})
+
+			Context("the fourth entry is selected, the view is rendered, and then the second entry is selected", func() {
+				BeforeEach(func() {
+					navigator.SelectNextEntry()
+					navigator.SelectNextEntry()
+					navigator.SelectNextEntry()
+					_ = navigator.View(maxRows)
+					navigator.SelectPreviousEntry()
+					navigator.SelectPreviousEntry()
+				})
+
+				It("returns the second row", func() {
+					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[1].Name))
+				})
+
+				It("returns the third row", func() {
+					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[2].Name))
+				})
+
+				It("sets the second row as highlighted", func() {
+					Expect(buffer.Rows[0].Highlight).To(BeTrue())
+				})
+			})
+		})
+
+		Context("in an empty directory", func() {
+			var emptyDirectoryPath string
+
+			BeforeEach(func() {
+				// Create an empty directory.
+				path, _ = os.Getwd()
+				fileInfo, _ := os.Stat(path + "/sample/")
+				emptyDirectoryPath = path + "/sample/empty"
+				_ = os.Mkdir(emptyDirectoryPath, fileInfo.Mode())
+
+				// Navigate into the empty directory.
+				navigator.SetWorkingDirectory(emptyDirectoryPath)
+			})
+
+			AfterEach(func() {
+				os.Remove(emptyDirectoryPath)
+			})
+
+			It("does not panic", func() {
+				Expect(func() { navigator.View(1) }).ToNot(Panic())
+			})
+
+			It("returns an empty buffer", func() {
+				buffer := navigator.View(1)
+				Expect(len(buffer.Rows)).To(BeZero())
+			})
+		})
+
+		Context("after a file has been removed", func() {
+			BeforeEach(func() {
+				maxRows = 1
+
+				// Create a new file.
+				file_name := "new_file"
+				os.Create(file_name)
+
+				// Update the navigator's cached entries.
+				navigator.SetWorkingDirectory(originalPath)
+
+				// Select the newly created file.
+				for navigator.SelectedEntry().Name != file_name {
+					navigator.SelectNextEntry()
+				}
+
+				// Remove it.
+				navigator.RemoveSelectedEntry()
+			})
+
+			It("does not panic", func() {
+				Expect(func() { navigator.View(maxRows) }).ToNot(Panic())
+			})
+		})
+	})
+
+	Describe("help overlay", func() {
+		var buffer *view.Buffer
+
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+			navigator.ShowHelp()
+		})
+
+		Context("there is room for every binding", func() {
+			BeforeEach(func() {
+				buffer = navigator.View(100)
+			})
+
+			It("lists the bindings in place of the entries", func() {
+				Expect(buffer.Rows[0].Left).To(MatchRegexp(`^j +Select the next entry$`))
+			})
+
+			It("uses a single column", func() {
+				Expect(buffer.Rows[0].Right).To(BeEmpty())
+			})
+		})
+
+		Context("there isn't room for every binding", func() {
+			var originalScreen view.Renderer
+
+			BeforeEach(func() {
+				originalScreen = view.Screen
+				view.Screen = view.NewMemoryScreen(80, 13)
+				buffer = navigator.View(12)
+			})
+
+			AfterEach(func() {
+				view.Screen = originalScreen
+			})
+
+			It("spreads the bindings over columns that fit on the screen", func() {
+				Expect(len(buffer.Rows)).To(BeNumerically("<=", 12))
+				Expect(buffer.Rows[0].Left).To(HavePrefix("j "))
+				for _, row := range buffer.Rows {
+					Expect(view.Width(row.Left)).To(BeNumerically("<=", 80))
+				}
+			})
+
+			It("lists every binding", func() {
+				var lines []string
+				for _, row := range buffer.Rows {
+					lines = append(lines, row.Left)
+				}
+				for _, binding := range input.Bindings() {
+					keys := regexp.QuoteMeta(strings.Join(binding.Keys, ", "))
+					Expect(strings.Join(lines, "\n")).To(MatchRegexp(`(^|\s)` + keys + `  `))
+				}
+			})
+		})
+
+		Context("the overlay is hidden", func() {
+			BeforeEach(func() {
+				navigator.HideHelp()
+				buffer = navigator.View(100)
+			})
+
+			It("lists the entries again", func() {
+				Expect(buffer.Rows[0].Left).To(Equal("directory/"))
+			})
+		})
+	})
+
+	Describe("applyChanges", func() {
+		var directoryPath string
+
+		// Waits for the pending directory sizes to be calculated.
+		calculate := func() {
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+		}
+
+		names := func() (result []string) {
+			for _, entry := range navigator.Entries() {
+				result = append(result, entry.Name)
+			}
+			return
+		}
+
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(directoryPath+"/directory", 0755)
+			ioutil.WriteFile(directoryPath+"/file", []byte("data"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			calculate()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
+
+		It("adds created entries", func() {
+			ioutil.WriteFile(directoryPath+"/created", []byte("data"), 0644)
+			navigator.recordChange("created")
+			navigator.applyChanges()
+
+			Expect(names()).To(ContainElement("created"))
+			Expect(navigator.Entries()).To(HaveLen(3))
+		})
+
+		It("removes deleted entries", func() {
+			os.Remove(directoryPath + "/file")
+			navigator.recordChange("file")
+			navigator.applyChanges()
+
+			Expect(names()).ToNot(ContainElement("file"))
+			Expect(navigator.Entries()).To(HaveLen(1))
+		})
+
+		It("refreshes modified files", func() {
+			ioutil.WriteFile(directoryPath+"/file", []byte("more data"), 0644)
+			navigator.recordChange("file")
+			navigator.applyChanges()
+
+			Expect(navigator.Entries()[1].Size).To(BeEquivalentTo(9))
+		})
+
+		It("re-calculates the sizes of modified directories", func(done Done) {
+			entry := navigator.Entries()[0]
+			originalSize := entry.Size
+
+			ioutil.WriteFile(directoryPath+"/directory/file", []byte("data"), 0644)
+			navigator.recordChange("directory")
+			navigator.applyChanges()
+
+			// The previous size remains until the new one has been calculated.
+			Expect(entry.Size).To(Equal(originalSize))
+			Expect(navigator.pendingCalculations).To(Equal(1))
+
+			calculate()
+			Expect(navigator.Entries()[0]).To(BeIdenticalTo(entry))
+			Expect(entry.Size).To(Equal(originalSize + 4))
+			close(done)
+		})
+
+		It("keeps the same entry selected", func() {
+			navigator.SelectNextEntry()
+			ioutil.WriteFile(directoryPath+"/a", []byte("data"), 0644)
+			navigator.recordChange("a")
+			navigator.applyChanges()
+
+			Expect(navigator.SelectedEntry().Name).To(Equal("file"))
+		})
+
+		Describe("Refresh", func() {
+			It("re-reads the directory", func() {
+				ioutil.WriteFile(directoryPath+"/created", []byte("data"), 0644)
+				os.Remove(directoryPath + "/file")
+				navigator.Refresh()
+
+				Expect(names()).To(Equal([]string{"created", "directory"}))
+			})
+
+			It("re-calculates directory sizes", func(done Done) {
+				ioutil.WriteFile(directoryPath+"/directory/file", []byte("data"), 0644)
+				navigator.Refresh()
+				Expect(navigator.pendingCalculations).To(Equal(1))
+
+				calculate()
+				Expect(navigator.Entries()[0].Items).To(BeEquivalentTo(1))
+				close(done)
+			})
+		})
+
+		Describe("RescanSelectedEntry", func() {
+			It("re-calculates the selected entry's size", func(done Done) {
+				ioutil.WriteFile(directoryPath+"/directory/file", []byte("data"), 0644)
+				Expect(navigator.RescanSelectedEntry()).To(Succeed())
+				Expect(navigator.pendingCalculations).To(Equal(1))
+
+				calculate()
+				Expect(navigator.Entries()[0].Items).To(BeEquivalentTo(1))
+				close(done)
+			})
+
+			It("leaves the other entries as they are", func() {
+				ioutil.WriteFile(directoryPath+"/file", []byte("more data"), 0644)
+				ioutil.WriteFile(directoryPath+"/created", []byte("data"), 0644)
+				navigator.RescanSelectedEntry()
+
+				Expect(names()).To(Equal([]string{"directory", "file"}))
+				Expect(navigator.Entries()[1].Size).To(BeEquivalentTo(4))
+			})
+
+			It("refreshes the selected file", func() {
+				navigator.SelectNextEntry()
+				ioutil.WriteFile(directoryPath+"/file", []byte("more data"), 0644)
+				navigator.RescanSelectedEntry()
+
+				Expect(navigator.SelectedEntry().Size).To(BeEquivalentTo(9))
+				Expect(navigator.pendingCalculations).To(BeZero())
+			})
+
+			Context("when the directory is empty", func() {
+				BeforeEach(func() {
+					navigator.SetWorkingDirectory(directoryPath + "/directory")
+				})
+
+				It("returns an error", func() {
+					Expect(navigator.RescanSelectedEntry()).ToNot(Succeed())
+				})
+			})
+		})
+	})
+
+	Describe("ToggleLargestFiles", func() {
+		BeforeEach(func() {
+			navigator.SetWorkingDirectory(originalPath + "/sample")
+			navigator.ToggleLargestFiles()
+		})
+
+		It("searches for the largest files below the current directory", func(done Done) {
+			Expect(navigator.Entries()).To(BeEmpty())
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("Largest files  [size desc]  Scanning"))
+
+			navigator.showLargestFiles(<-navigator.largestFilesFound)
+			Expect(navigator.Entries()).To(HaveLen(4))
+			Expect(navigator.Entries()[2].Name).To(Equal("small_file"))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("4 largest files  [size desc]"))
+			close(done)
+		})
+
+		It("removes the selected file from its subdirectory", func(done Done) {
+			directoryPath, _ := ioutil.TempDir("", "purge")
+			defer os.RemoveAll(directoryPath)
+			os.Mkdir(directoryPath+"/nested", 0755)
+			ioutil.WriteFile(directoryPath+"/nested/large", []byte("large file"), 0644)
+			ioutil.WriteFile(directoryPath+"/small", []byte("small"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			navigator.ToggleLargestFiles()
+			navigator.showLargestFiles(<-navigator.largestFilesFound)
+			Expect(navigator.SelectedEntry().Name).To(Equal("nested/large"))
+
+			Expect(navigator.RemoveSelectedEntry()).To(Succeed())
+			_, err := os.Stat(directoryPath + "/nested/large")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			Expect(Session.Freed()).To(BeEquivalentTo(10))
+			close(done)
+		})
+
+		It("goes back to the directory's entries when toggled again", func(done Done) {
+			navigator.showLargestFiles(<-navigator.largestFilesFound)
+			navigator.ToggleLargestFiles()
+
+			Expect(navigator.Entries()).To(HaveLen(4))
+			Expect(navigator.Entries()[0].Name).To(Equal("directory"))
+			Expect(navigator.View(10).Status[1]).ToNot(ContainSubstring("largest files"))
+			close(done)
+		})
+
+		It("abandons the search when toggled again before it's finished", func() {
+			abandoned := navigator.abandonSearch
+			navigator.ToggleLargestFiles()
+			Expect(abandoned).To(BeClosed())
+			Expect(navigator.abandonSearch).To(BeNil())
+		})
+	})
+
+	Describe("ToggleTree", func() {
+		var directoryPath string
+
+		// Returns the names displayed for the entries.
+		rows := func() (result []string) {
+			for _, row := range navigator.View(20).Rows {
+				result = append(result, row.Left)
+			}
+			return
+		}
+
+		// Selects and expands (or collapses) the named entry.
+		toggle := func(name string) {
+			for index, entry := range navigator.Entries() {
+				if entry.Name == name {
+					navigator.SelectEntry(index)
+				}
+			}
+			Expect(navigator.ToggleSelectedEntryExpanded()).To(Succeed())
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+		}
+
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.MkdirAll(directoryPath+"/a/b/c", 0755)
+			os.Mkdir(directoryPath+"/a/d", 0755)
+			ioutil.WriteFile(directoryPath+"/a/b/file", []byte("data"), 0644)
+			ioutil.WriteFile(directoryPath+"/e", []byte("data"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+			navigator.ToggleTree()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
+
+		It("lists the entries of expanded directories beneath them", func() {
+			toggle("a")
+			Expect(rows()).To(Equal([]string{"a/", "├─ b/", "└─ d/", "e"}))
+			Expect(navigator.Entries()[1].Name).To(Equal("a/b"))
+		})
+
+		It("indents nested entries with guides", func() {
+			toggle("a")
+			toggle("a/b")
+			Expect(rows()).To(Equal([]string{"a/", "├─ b/", "│  ├─ c/", "│  └─ file", "└─ d/", "e"}))
+		})
+
+		It("calculates the sizes of nested directories", func() {
+			toggle("a")
+			Expect(navigator.Entries()[1].SizeCalculated).To(BeTrue())
+			Expect(navigator.Entries()[1].Size).To(BeEquivalentTo(4))
+			Expect(navigator.View(20).Rows[1].Right).To(Equal("4 bytes"))
+		})
+
+		It("collapses expanded directories", func() {
+			toggle("a")
+			toggle("a/b")
+			toggle("a")
+			Expect(rows()).To(Equal([]string{"a/", "e"}))
+
+			// Nested directories are collapsed along with it.
+			toggle("a")
+			Expect(rows()).To(Equal([]string{"a/", "├─ b/", "└─ d/", "e"}))
+		})
+
+		It("keeps the selected entry selected", func() {
+			toggle("a")
+			Expect(navigator.SelectedEntry().Name).To(Equal("a"))
+		})
+
+		It("removes nested entries, deducting their size from the directories above", func() {
+			toggle("a")
+			toggle("a/b")
+			navigator.SelectEntry(3)
+			Expect(navigator.RemoveSelectedEntry()).To(Succeed())
+
+			Expect(rows()).To(Equal([]string{"a/", "├─ b/", "│  └─ c/", "└─ d/", "e"}))
+			Expect(navigator.Entries()[0].Size).To(BeZero())
+			Expect(navigator.SelectedEntry().Name).To(Equal("a/d"))
+		})
+
+		It("goes back to listing the directory's own entries when toggled again", func() {
+			toggle("a")
+			navigator.ToggleTree()
+			Expect(rows()).To(Equal([]string{"a/", "e"}))
+		})
+
+		Context("when the tree isn't being listed", func() {
+			BeforeEach(func() {
+				navigator.ToggleTree()
+			})
+
+			It("doesn't expand directories", func() {
+				toggle("a")
+				Expect(rows()).To(Equal([]string{"a/", "e"}))
+			})
+		})
+	})
+
+	Describe("ToggleExtensions", func() {
+		var directoryPath string
+
+		calculate := func() {
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+		}
+
+		rows := func() (result []string) {
+			for _, row := range navigator.View(10).Rows {
+				result = append(result, row.Left+" "+row.Right)
+			}
+			return
+		}
+
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(directoryPath+"/logs", 0755)
+			ioutil.WriteFile(directoryPath+"/logs/a.log", []byte("aaaa"), 0644)
+			ioutil.WriteFile(directoryPath+"/logs/b.LOG", []byte("bbbbbb"), 0644)
+			ioutil.WriteFile(directoryPath+"/video.mp4", []byte("video"), 0644)
+			ioutil.WriteFile(directoryPath+"/README", []byte("read"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			calculate()
+			navigator.ToggleExtensions()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
+
+		It("lists the space used by each extension, with its number of files", func() {
+			navigator.SetFilter("")
+			Expect(rows()).To(ConsistOf(".log (2 files) 10 bytes", ".mp4 (1 file) 5 bytes", "(no extension) (1 file) 4 bytes"))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("3 extensions  "))
+		})
+
+		It("can be sorted by size", func() {
+			navigator.sortOrder = directory.SortOrder{Mode: directory.SortBySize}
+			navigator.sortEntries()
+			Expect(navigator.Entries()[0].Name).To(Equal(".log"))
+			Expect(navigator.Entries()[2].Name).To(Equal(noExtension))
+		})
+
+		It("adds up directories' files as their sizes are calculated", func(done Done) {
+			navigator.ToggleExtensions()
+			navigator.ToggleExtensions()
+			Expect(navigator.Entries()).To(HaveLen(2))
+
+			calculate()
+			Expect(navigator.Entries()).To(HaveLen(3))
+			close(done)
+		})
+
+		It("lists the largest files of the selected type when opened", func(done Done) {
+			navigator.sortOrder = directory.SortOrder{Mode: directory.SortBySize}
+			navigator.sortEntries()
+			navigator.SelectFirstEntry()
+			Expect(navigator.IntoSelectedEntry()).To(Succeed())
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("Largest .log files"))
+
+			navigator.showLargestFiles(<-navigator.largestFilesFound)
+			Expect(rows()).To(Equal([]string{"logs/b.LOG 6 bytes", "logs/a.log 4 bytes"}))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("2 largest .log files"))
+
+			navigator.ToggleLargestFiles()
+			Expect(navigator.Entries()).To(HaveLen(3))
+			Expect(navigator.SortOrder().Mode).To(Equal(directory.SortByName))
+			close(done)
+		})
+
+		It("doesn't remove types of file", func() {
+			Expect(navigator.RemoveSelectedEntry()).To(MatchError(errBreakdownSelected))
+			Expect(navigator.Entries()).To(HaveLen(3))
+		})
+
+		It("lists the space used by each category, when toggled", func() {
+			navigator.ToggleCategories()
+			Expect(rows()).To(ConsistOf("logs (2 files) 10 bytes", "video (1 file) 5 bytes", "other (1 file) 4 bytes"))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("3 categories  "))
+		})
+
+		It("goes back to the directory's entries when toggled again", func() {
+			navigator.ToggleExtensions()
+			calculate()
+			Expect(rows()).To(ConsistOf("README 4 bytes", "logs/ 10 bytes", "video.mp4 5 bytes"))
+		})
+	})
+
+	Describe("ToggleOwners", func() {
+		var directoryPath string
+		owner := directory.OwnerName(uint32(os.Getuid()))
+		group := directory.GroupName(uint32(os.Getgid()))
+
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(directoryPath+"/nested", 0755)
+			ioutil.WriteFile(directoryPath+"/nested/file", []byte("nested"), 0644)
+			ioutil.WriteFile(directoryPath+"/file", []byte("file"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+			navigator.ToggleOwners()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
+
+		It("lists the space used by each owner, with their number of files", func() {
+			Expect(navigator.Entries()).To(HaveLen(1))
+			Expect(navigator.View(10).Rows[0].Left).To(Equal(owner + " (2 files)"))
+			Expect(navigator.Entries()[0].Size).To(BeEquivalentTo(10))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("1 owner  "))
+		})
+
+		It("lists the largest files of the selected owner when opened", func(done Done) {
+			Expect(navigator.IntoSelectedEntry()).To(Succeed())
+			navigator.showLargestFiles(<-navigator.largestFilesFound)
+			Expect(navigator.Entries()).To(HaveLen(2))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("2 largest files owned by " + owner))
+			close(done)
+		})
+
+		It("lists the space used by each group, when toggled", func() {
+			navigator.ToggleGroups()
+			Expect(navigator.View(10).Rows[0].Left).To(Equal(group + " (2 files)"))
+			Expect(navigator.View(10).Status[1]).To(HavePrefix("1 group  "))
+		})
 	})
 
-	Describe("View", func() {
-		var buffer *view.Buffer
-		var maxRows int
+	Describe("ToggleExcluded", func() {
+		var directoryPath string
+
+		calculate := func() {
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+		}
+
+		names := func() (result []string) {
+			for _, entry := range navigator.Entries() {
+				result = append(result, entry.Name)
+			}
+			return
+		}
 
 		BeforeEach(func() {
-			navigator.SetWorkingDirectory(originalPath)
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.MkdirAll(directoryPath+"/project/.git", 0755)
+			ioutil.WriteFile(directoryPath+"/project/.git/pack", []byte("packed"), 0644)
+			ioutil.WriteFile(directoryPath+"/project/main.go", []byte("main"), 0644)
+			os.Mkdir(directoryPath+"/daily.snapshot", 0755)
+			ioutil.WriteFile(directoryPath+"/daily.snapshot/copy", []byte("copy"), 0644)
+
+			list, _ := directory.NewExcludeList([]string{".git/", "*.snapshot"})
+			directory.SetExclude(list)
+			navigator.SetWorkingDirectory(directoryPath)
+			calculate()
 		})
 
-		JustBeforeEach(func() {
-			buffer = navigator.View(maxRows)
+		AfterEach(func() {
+			directory.SetExclude(nil)
+			os.RemoveAll(directoryPath)
 		})
 
-		Describe("Status Line", func() {
-			Context("when all calculations have completed", func() {
-				BeforeEach(func() {
-					// FIXME: This calculation gets stuck at 75%.
-					// Hijack the navigator to simulate calculation completion.
-					navigator.pendingCalculations = 0
-				})
+		It("hides excluded entries, without calculating their sizes", func() {
+			Expect(names()).To(Equal([]string{"project"}))
+			Expect(navigator.View(10).Status[1]).To(HaveSuffix("  1 excluded"))
+		})
 
-				It("returns the current directory path as its first element", func() {
-					Expect(buffer.Status[0]).To(Equal(navigator.CurrentPath()))
-				})
+		It("leaves excluded entries out of directory sizes", func() {
+			Expect(navigator.Entries()[0].Size).To(BeEquivalentTo(4))
+		})
 
-				It("returns disk/partition space statistics as its second element", func() {
-					avail := int64(navigator.availableBytes())
-					total := int64(navigator.totalBytes())
-					status := fmt.Sprintf("%v available (%v%% used)", view.Size(avail), (total-avail)*100/total)
+		It("lists excluded entries with their sizes once revealed", func(done Done) {
+			navigator.ToggleExcluded()
+			Expect(navigator.ExcludedRevealed()).To(BeTrue())
+			Expect(names()).To(Equal([]string{"daily.snapshot", "project"}))
+
+			calculate()
+			row := navigator.View(10).Rows[0]
+			Expect(row.Right).To(Equal("4 bytes"))
+			Expect(row.Details.Excluded).To(BeTrue())
+			Expect(row.Details.Fraction).To(BeZero())
+			Expect(navigator.View(10).Rows[1].Details.Fraction).To(BeNumerically("==", 1))
+			close(done)
+		})
 
-					Expect(buffer.Status[1]).To(Equal(status))
-				})
-			})
+		It("lists excluded entries in expanded directories once revealed", func() {
+			navigator.ToggleTree()
+			navigator.SelectEntry(0)
+			navigator.ToggleSelectedEntryExpanded()
+			Expect(names()).To(Equal([]string{"project", "project/main.go"}))
+
+			navigator.ToggleExcluded()
+			Expect(names()).To(Equal([]string{"daily.snapshot", "project", "project/.git", "project/main.go"}))
 		})
-		Context("maxRows is set to 1", func() {
+	})
+
+	Describe("ToggleHidden", func() {
+		var directoryPath string
+
+		names := func() (result []string) {
+			for _, entry := range navigator.Entries() {
+				result = append(result, entry.Name)
+			}
+			return
+		}
+
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(directoryPath+"/.cache", 0755)
+			ioutil.WriteFile(directoryPath+"/.cache/blob", []byte("cached blob"), 0644)
+			ioutil.WriteFile(directoryPath+"/.profile", []byte("profile"), 0644)
+			os.Mkdir(directoryPath+"/src", 0755)
+			ioutil.WriteFile(directoryPath+"/src/.env", []byte("env"), 0644)
+			ioutil.WriteFile(directoryPath+"/src/main.go", []byte("main"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			for navigator.pendingCalculations > 0 {
+				navigator.updateEntrySize(<-navigator.DirectorySizes)
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
+
+		It("lists hidden entries by default, and says so", func() {
+			Expect(navigator.HiddenShown()).To(BeTrue())
+			Expect(names()).To(Equal([]string{".cache", ".profile", "src"}))
+			Expect(navigator.View(10).Status[1]).To(HaveSuffix("  hidden shown"))
+		})
+
+		It("leaves hidden entries out, and says how many there are, when toggled", func() {
+			navigator.ToggleHidden()
+			Expect(navigator.HiddenShown()).To(BeFalse())
+			Expect(names()).To(Equal([]string{"src"}))
+			Expect(navigator.View(10).Status[1]).To(HaveSuffix("  2 hidden"))
+		})
+
+		It("still counts hidden entries towards directory sizes", func() {
+			navigator.ToggleHidden()
+			Expect(navigator.Entries()[0].Size).To(BeEquivalentTo(7))
+			Expect(navigator.View(10).Rows[0].Details.Fraction).To(BeNumerically("~", 7.0/25))
+		})
+
+		It("leaves hidden entries out of expanded directories", func() {
+			navigator.ToggleHidden()
+			navigator.ToggleTree()
+			navigator.ToggleSelectedEntryExpanded()
+			Expect(names()).To(Equal([]string{"src", "src/main.go"}))
+			Expect(navigator.View(10).Status[1]).To(HaveSuffix("  3 hidden"))
+		})
+
+		It("lists hidden entries again when toggled back", func() {
+			navigator.ToggleHidden()
+			navigator.ToggleHidden()
+			Expect(names()).To(HaveLen(3))
+		})
+
+		Context("a hidden entry is excluded", func() {
 			BeforeEach(func() {
-				maxRows = 1
+				list, _ := directory.NewExcludeList([]string{".cache"})
+				directory.SetExclude(list)
+				navigator.SetWorkingDirectory(directoryPath)
+				for navigator.pendingCalculations > 0 {
+					navigator.updateEntrySize(<-navigator.DirectorySizes)
+				}
 			})
 
-			It("returns a buffer with the right number of rows", func() {
-				Expect(len(buffer.Rows)).To(BeEquivalentTo(maxRows))
+			AfterEach(func() {
+				directory.SetExclude(nil)
 			})
 
-			It("stores the proper view data indices", func() {
-				Expect(navigator.ViewDataIndices()).To(Equal([2]int{0, 1}))
+			It("only counts it as excluded", func() {
+				navigator.ToggleHidden()
+				Expect(navigator.View(10).Status[1]).To(HaveSuffix("  1 hidden  1 excluded"))
 			})
 
-			Describe("returned row", func() {
-				It("has its left value set to the first entry's name", func() {
-					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[0].Name))
-				})
-
-				It("has its right value set to the first entry's formatted size", func() {
-					formattedSize := view.Size(navigator.Entries()[0].Size)
-					Expect(buffer.Rows[0].Right).To(Equal(formattedSize))
-				})
+			It("counts it as hidden once excluded entries are revealed", func() {
+				navigator.ToggleHidden()
+				navigator.ToggleExcluded()
+				Expect(navigator.View(10).Status[1]).To(HaveSuffix("  2 hidden"))
+			})
+		})
+	})
 
-				It("has its highlight value set to the first entry's highlighted status", func() {
-					Expect(buffer.Rows[0].Highlight).To(BeTrue())
-				})
+	Describe("ToggleDuplicates", func() {
+		var directoryPath string
 
-				Context("selected entry is a directory", func() {
-					BeforeEach(func() {
-						entry := navigator.SelectedEntry()
+		// Returns the names displayed for the entries.
+		rows := func() (result []string) {
+			for _, row := range navigator.View(20).Rows {
+				result = append(result, row.Left)
+			}
+			return
+		}
+
+		// Selects the named entry.
+		selectEntry := func(name string) {
+			for index, entry := range navigator.Entries() {
+				if entry.Name == name {
+					navigator.SelectEntry(index)
+				}
+			}
+		}
 
-						for !entry.IsDirectory {
-							navigator.SelectNextEntry()
-							entry = navigator.SelectedEntry()
-						}
-					})
+		BeforeEach(func() {
+			directoryPath, _ = ioutil.TempDir("", "purge")
+			os.Mkdir(directoryPath+"/directory", 0755)
+			ioutil.WriteFile(directoryPath+"/a", []byte("data"), 0644)
+			ioutil.WriteFile(directoryPath+"/directory/b", []byte("data"), 0644)
+			ioutil.WriteFile(directoryPath+"/c", []byte("data"), 0644)
+			ioutil.WriteFile(directoryPath+"/unique", []byte("unique"), 0644)
+
+			navigator.SetWorkingDirectory(directoryPath)
+			navigator.ToggleDuplicates()
+			navigator.showDuplicates(<-navigator.duplicatesFound)
+		})
 
-					It("has its colour value set to true", func() {
-						Expect(buffer.Rows[0].Colour).To(BeTrue())
-					})
+		AfterEach(func() {
+			os.RemoveAll(directoryPath)
+		})
 
-					It("has a forward slash appended to its name", func() {
-						Expect(buffer.Rows[0].Left).To(Equal(navigator.SelectedEntry().Name + "/"))
-					})
-				})
+		It("lists duplicates in groups", func() {
+			Expect(rows()).To(Equal([]string{"┌─ a", "├─ c", "└─ directory/b"}))
+			Expect(navigator.View(20).Status[1]).To(HavePrefix("1 group of duplicates, 8 bytes wasted"))
+		})
 
-				Context("selected entry is not a directory", func() {
-					BeforeEach(func() {
-						entry := navigator.SelectedEntry()
+		It("keeps duplicates in their groups when sorting", func() {
+			navigator.ReverseSortOrder()
+			Expect(rows()).To(Equal([]string{"┌─ a", "├─ c", "└─ directory/b"}))
+		})
 
-						for entry.IsDirectory {
-							navigator.SelectNextEntry()
-							entry = navigator.SelectedEntry()
-						}
-					})
+		It("removes single copies, and stops listing the last one", func() {
+			Expect(navigator.RemoveSelectedEntry()).To(Succeed())
+			Expect(rows()).To(Equal([]string{"┌─ c", "└─ directory/b"}))
 
-					It("has its colour value set to false", func() {
-						Expect(buffer.Rows[0].Colour).To(BeFalse())
-					})
-				})
-			})
+			Expect(navigator.RemoveSelectedEntry()).To(Succeed())
+			Expect(rows()).To(BeEmpty())
+			Expect(directoryPath + "/directory/b").To(BeAnExistingFile())
 		})
 
-		Context("maxRows is set to 2", func() {
-			BeforeEach(func() {
-				maxRows = 2
+		Describe("RemoveOtherDuplicates", func() {
+			It("removes every other copy", func() {
+				selectEntry("directory/b")
+				Expect(navigator.RemoveOtherDuplicates()).To(BeEquivalentTo(8))
+
+				Expect(directoryPath + "/a").ToNot(BeAnExistingFile())
+				Expect(directoryPath + "/c").ToNot(BeAnExistingFile())
+				Expect(directoryPath + "/directory/b").To(BeAnExistingFile())
+				Expect(rows()).To(BeEmpty())
+				Expect(Session.Freed()).To(BeEquivalentTo(8))
 			})
 
-			It("returns a buffer with the right number of rows", func() {
-				Expect(len(buffer.Rows)).To(BeEquivalentTo(maxRows))
+			It("stops if a copy has changed", func() {
+				ioutil.WriteFile(directoryPath+"/c", []byte("date"), 0644)
+				selectEntry("directory/b")
+				_, err := navigator.RemoveOtherDuplicates()
+
+				Expect(err).To(MatchError("c has changed"))
+				Expect(directoryPath + "/c").To(BeAnExistingFile())
+				Expect(rows()).To(Equal([]string{"┌─ c", "└─ directory/b"}))
 			})
 
-			It("stores the proper view data indices", func() {
-				Expect(navigator.ViewDataIndices()).To(Equal([2]int{0, 2}))
+			It("returns an error for entries that aren't duplicates", func() {
+				navigator.ToggleDuplicates()
+				_, err := navigator.RemoveOtherDuplicates()
+				Expect(err).To(Equal(errNotDuplicate))
 			})
+		})
 
-			Context("selected entry has never been changed", func() {
-				It("returns the first and second rows", func() {
-					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[0].Name))
-					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[1].Name))
-				})
+		Describe("LinkDuplicates", func() {
+			BeforeEach(func() {
+				AuditLog, _ = audit.Open(directoryPath + "/audit/audit.log")
+			})
 
-				It("sets the first row as highlighted", func() {
-					Expect(buffer.Rows[0].Highlight).To(BeTrue())
-				})
+			AfterEach(func() {
+				AuditLog.Close()
+				AuditLog = nil
 			})
 
-			Context("the second entry is selected", func() {
-				BeforeEach(func() {
-					navigator.SelectNextEntry()
-				})
+			It("replaces every other copy with a hard link", func() {
+				selectEntry("c")
+				Expect(navigator.LinkDuplicates()).To(BeEquivalentTo(8))
 
-				It("returns the first row", func() {
-					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[0].Name))
-				})
+				selected, _ := os.Stat(directoryPath + "/c")
+				for _, name := range []string{"a", "directory/b"} {
+					linked, _ := os.Stat(directoryPath + "/" + name)
+					Expect(os.SameFile(selected, linked)).To(BeTrue())
+				}
+				Expect(rows()).To(BeEmpty())
+				Expect(Session.Freed()).To(BeEquivalentTo(8))
+			})
 
-				It("returns the second row", func() {
-					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[1].Name))
-				})
+			It("logs the replacements", func() {
+				navigator.LinkDuplicates()
 
-				It("sets the second row as highlighted", func() {
-					Expect(buffer.Rows[1].Highlight).To(BeTrue())
-				})
+				data, _ := ioutil.ReadFile(directoryPath + "/audit/audit.log")
+				lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+				Expect(lines).To(HaveLen(4))
+
+				var record audit.Record
+				json.Unmarshal([]byte(lines[3]), &record)
+				Expect(record.Result).To(Equal(audit.Linked))
+				Expect(record.Path).To(Equal(directoryPath + "/directory/b"))
 			})
+		})
 
-			Context("the second entry is selected, the view is rendered, and then the third entry is selected", func() {
-				BeforeEach(func() {
-					navigator.SelectNextEntry()
-					_ = navigator.View(maxRows)
-					navigator.SelectNextEntry()
-				})
+		It("abandons the search when toggled again before it's finished", func() {
+			navigator.ToggleDuplicates()
+			navigator.ToggleDuplicates()
+			abandoned := navigator.abandonSearch
+			navigator.ToggleDuplicates()
+			Expect(abandoned).To(BeClosed())
+			Expect(navigator.abandonSearch).To(BeNil())
+		})
 
-				It("returns the second row", func() {
-					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[1].Name))
-				})
+		It("reports progress while comparing files' contents", func() {
+			navigator.scan = directory.NewScan()
+			directory.FindDuplicates(directoryPath, nil, navigator.scan)
+			Expect(navigator.progressDescription()).To(MatchRegexp(`^Hashing .+: 24 bytes hashed$`))
+		})
+	})
 
-				It("returns the third row", func() {
-					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[2].Name))
-				})
+	Describe("NewNavigator", func() {
+		var (
+			screen         *view.MemoryScreen
+			originalScreen view.Renderer
+			commands       chan input.Command
+			viewStopped    chan bool
+			previousFrames int
+			startPath      string
+			screenWidth    int
+			screenHeight   int
+		)
+
+		// Sends a command, waiting for the screen to be redrawn afterwards.
+		send := func(command input.Command) {
+			previousFrames = len(screen.Frames())
+			commands <- command
+			Eventually(func() int { return len(screen.Frames()) }).Should(BeNumerically(">", previousFrames))
+		}
 
-				It("sets the third row as highlighted", func() {
-					Expect(buffer.Rows[1].Highlight).To(BeTrue())
-				})
-			})
+		BeforeEach(func() {
+			startPath = originalPath + "/sample"
+			screenWidth, screenHeight = 100, 8
+		})
 
-			Context("the third entry is selected, the view is rendered, and then the second entry is selected", func() {
-				BeforeEach(func() {
-					navigator.SelectNextEntry()
-					navigator.SelectNextEntry()
-					_ = navigator.View(maxRows)
-					navigator.SelectPreviousEntry()
-				})
+		JustBeforeEach(func() {
+			originalScreen = view.Screen
+			screen = view.NewMemoryScreen(screenWidth, screenHeight)
+			view.Screen = screen
+
+			commands = make(chan input.Command)
+			buffers := make(chan *view.Buffer)
+			viewStopped = make(chan bool)
+
+			go func() {
+				view.New(buffers)
+				close(viewStopped)
+			}()
+			go func() {
+				NewNavigator(startPath, commands, buffers)
+				close(buffers)
+			}()
+
+			// Wait for every directory size to be calculated.
+			Eventually(screen.Frame).Should(ContainSubstring("directory/"))
+			Eventually(screen.Frame).ShouldNot(ContainSubstring("Scanning"))
+		})
 
-				It("returns the second row", func() {
-					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[1].Name))
-				})
+		AfterEach(func() {
+			close(commands)
+			Eventually(viewStopped).Should(BeClosed())
+			view.Screen = originalScreen
+		})
 
-				It("returns the third row", func() {
-					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[2].Name))
-				})
+		It("renders the entries and the status line", func() {
+			lines := strings.Split(screen.Frame(), "\n")
+			Expect(lines[0]).To(HavePrefix("directory/"))
+			Expect(lines[2]).To(HavePrefix("file"))
+			Expect(lines[2]).To(ContainSubstring("250.0 KB"))
+			Expect(lines[7]).To(ContainSubstring("sample"))
+		})
 
-				It("sets the second row as highlighted", func() {
-					Expect(buffer.Rows[0].Highlight).To(BeTrue())
-				})
-			})
+		It("highlights the selected entry", func() {
+			send(input.Command{Name: "SelectNextEntry", Count: 2})
+			Expect(screen.Cell(0, 2).Style).ToNot(Equal(screen.Cell(0, 1).Style))
+			Expect(screen.Cell(0, 1).Style).To(Equal(screen.Cell(0, 3).Style))
+		})
 
-			Context("the fourth entry is selected, the view is rendered, and then the second entry is selected", func() {
-				BeforeEach(func() {
-					navigator.SelectNextEntry()
-					navigator.SelectNextEntry()
-					navigator.SelectNextEntry()
-					_ = navigator.View(maxRows)
-					navigator.SelectPreviousEntry()
-					navigator.SelectPreviousEntry()
-				})
+		It("renders filtered entries", func() {
+			send(input.Command{Name: "Filter"})
+			send(input.Command{Name: "ConfirmPrompt", Argument: "small"})
 
-				It("returns the second row", func() {
-					Expect(buffer.Rows[0].Left).To(ContainSubstring(navigator.Entries()[1].Name))
-				})
+			lines := strings.Split(screen.Frame(), "\n")
+			Expect(lines[0]).To(HavePrefix("small_file"))
+			Expect(lines[1]).To(BeEmpty())
+		})
 
-				It("returns the third row", func() {
-					Expect(buffer.Rows[1].Left).To(ContainSubstring(navigator.Entries()[2].Name))
-				})
+		It("reports errors until the next command", func() {
+			send(input.Command{Name: "SelectNextEntry", Count: 2})
+			send(input.Command{Name: "IntoSelectedEntry"})
+			Expect(screen.Frame()).To(ContainSubstring("Path is not a directory"))
 
-				It("sets the second row as highlighted", func() {
-					Expect(buffer.Rows[0].Highlight).To(BeTrue())
-				})
-			})
+			send(input.Command{Name: "SelectNextEntry"})
+			Expect(screen.Frame()).ToNot(ContainSubstring("Path is not a directory"))
 		})
 
-		Context("in an empty directory", func() {
-			var emptyDirectoryPath string
+		It("clears messages once they expire", func() {
+			originalDuration := MessageDuration
+			MessageDuration = 10 * time.Millisecond
+			defer func() { MessageDuration = originalDuration }()
 
-			BeforeEach(func() {
-				// Create an empty directory.
-				path, _ = os.Getwd()
-				fileInfo, _ := os.Stat(path + "/sample/")
-				emptyDirectoryPath = path + "/sample/empty"
-				_ = os.Mkdir(emptyDirectoryPath, fileInfo.Mode())
+			send(input.Command{Name: "Filter"})
+			send(input.Command{Name: "ConfirmPrompt", Argument: "/(/"})
+			Expect(screen.Frames()).To(ContainElement(ContainSubstring("Error parsing regexp")))
+			Eventually(screen.Frame).ShouldNot(ContainSubstring("Error parsing regexp"))
+		})
 
-				// Navigate into the empty directory.
-				navigator.SetWorkingDirectory(emptyDirectoryPath)
+		Context("in a directory that changes", func() {
+			BeforeEach(func() {
+				startPath, _ = ioutil.TempDir("", "purge")
+				os.Mkdir(startPath+"/directory", 0755)
 			})
 
 			AfterEach(func() {
-				os.Remove(emptyDirectoryPath)
+				os.RemoveAll(startPath)
 			})
 
-			It("does not panic", func() {
-				Expect(func() { navigator.View(1) }).ToNot(Panic())
-			})
+			It("reflects changes to the directory", func() {
+				// Changes are applied (and rendered) once the change delay has passed.
+				ioutil.WriteFile(startPath+"/watched_file", []byte("data"), 0644)
+				Eventually(screen.Frame, 2*time.Second).Should(ContainSubstring("watched_file"))
 
-			It("returns an empty buffer", func() {
-				buffer := navigator.View(1)
-				Expect(len(buffer.Rows)).To(BeZero())
+				os.Remove(startPath + "/watched_file")
+				Eventually(screen.Frame, 2*time.Second).ShouldNot(ContainSubstring("watched_file"))
 			})
 		})
 
-		Context("after a file has been removed", func() {
-			BeforeEach(func() {
-				maxRows = 1
-
-				// Create a new file.
-				file_name := "new_file"
-				os.Create(file_name)
-
-				// Update the navigator's cached entries.
-				navigator.SetWorkingDirectory(originalPath)
+		It("renders the help overlay", func() {
+			send(input.Command{Name: "ShowHelp"})
+			Expect(screen.Frame()).To(ContainSubstring("Press any key to return"))
 
-				// Select the newly created file.
-				for navigator.SelectedEntry().Name != file_name {
-					navigator.SelectNextEntry()
-				}
+			send(input.Command{Name: "HideHelp"})
+			Expect(screen.Frame()).ToNot(ContainSubstring("Press any key to return"))
+		})
 
-				// Remove it.
-				navigator.RemoveSelectedEntry()
+		Context("on an 80x24 screen", func() {
+			BeforeEach(func() {
+				screenWidth, screenHeight = 80, 24
 			})
 
-			It("does not panic", func() {
-				Expect(func() { navigator.View(maxRows) }).ToNot(Panic())
+			It("renders every key binding in the help overlay", func() {
+				send(input.Command{Name: "ShowHelp"})
+				for _, binding := range input.Bindings() {
+					keys := regexp.QuoteMeta(strings.Join(binding.Keys, ", "))
+					Expect(screen.Frame()).To(MatchRegexp(`(?m)(^| )` + keys + `  +\S`))
+				}
 			})
 		})
 	})
@@ -0,0 +1,51 @@
+package navigator
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jmacdonald/purge/view"
+)
+
+// How often the view is refreshed to report progress while sizes are calculated.
+var ProgressInterval = 250 * time.Millisecond
+
+// The width that the subtree being scanned is truncated to in the status line.
+const progressPathWidth = 30
+
+// Schedules a view refresh to report on the scan's
+// progress, if directory sizes are still being calculated.
+func (navigator *Navigator) scheduleProgress() {
+	if navigator.pendingCalculations > 0 && navigator.progressTick == nil {
+		navigator.progressTick = time.After(ProgressInterval)
+	} else if navigator.pendingCalculations == 0 {
+		navigator.progressTick = nil
+	}
+}
+
+// Describes the progress of the directory size calculations, e.g.
+// "Scanning src/lib: 1204 files, 1.2 GB at 80.0 MB/s", or of the
+// comparison of files' contents, e.g. "Hashing src/lib/a.o: 200.0 MB hashed".
+func (navigator *Navigator) progressDescription() string {
+	scan := navigator.scan
+	files, noun := scan.Files(), "files"
+	if files == 1 {
+		noun = "file"
+	}
+	verb := "Scanning"
+	description := fmt.Sprintf("%d %v, %v at %v/s", files, noun, view.Size(scan.Bytes()),
+		view.Size(int64(scan.Throughput())))
+	if hashed := scan.Hashed(); hashed > 0 {
+		verb = "Hashing"
+		description = fmt.Sprintf("%v hashed", view.Size(hashed))
+	}
+
+	// Name the subtree being walked, relative to the current directory.
+	current, err := filepath.Rel(navigator.currentPath, scan.Current())
+	if err != nil || current == "." {
+		return verb + ": " + description
+	}
+
+	return fmt.Sprintf("%v %v: %v", verb, view.Truncate(current, progressPathWidth), description)
+}
@@ -0,0 +1,223 @@
+package navigator
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// Structure used to keep track of an open search or filter prompt.
+type prompt struct {
+	command string
+	text    string
+
+	// The selected index when the prompt was opened, so
+	// that it can be restored if the prompt is cancelled.
+	origin int
+}
+
+// Returns the navigator's active filter pattern, or
+// an empty string if the entries aren't being filtered.
+func (navigator *Navigator) Filter() string {
+	return navigator.filter
+}
+
+// Restricts the navigator's entries to those with names matching the pattern.
+// Patterns wrapped in forward slashes (e.g. "/\.log$/") are treated as regular
+// expressions, and all others as globs; a glob without any wildcards matches
+// names that contain it. An empty pattern removes the filter.
+func (navigator *Navigator) SetFilter(pattern string) error {
+	match, err := compileFilter(pattern)
+	if err != nil {
+		return err
+	}
+
+	navigator.filter = pattern
+	navigator.filterMatch = match
+	navigator.applyFilter()
+
+	return nil
+}
+
+// Rebuilds the visible (sorted) entries from the complete set, keeping
+// the selected entry selected if it's still visible after filtering.
+func (navigator *Navigator) applyFilter() {
+	navigator.arrangeEntries()
+	navigator.viewDataIndices = [2]int{0, 0}
+}
+
+// Describes the active filters in the status line, e.g. "*.log, older than 180d",
+// or returns an empty string if the entries aren't being filtered.
+func (navigator *Navigator) filterDescription() string {
+	var filters []string
+	if navigator.filter != "" {
+		filters = append(filters, navigator.filter)
+	}
+	if navigator.ageFilter != "" {
+		filters = append(filters, "older than "+navigator.ageFilter)
+	}
+
+	return strings.Join(filters, ", ")
+}
+
+// Rebuilds the visible entries from the complete set, filtering and sorting
+// them (except for duplicates), leaving out excluded entries (unless they've
+// been revealed) and hidden ones (if they're being left out), and listing the entries of expanded directories beneath them when
+// showing a tree. The selected entry is kept selected if it's still visible.
+func (navigator *Navigator) arrangeEntries() {
+	selectedEntry := navigator.SelectedEntry()
+
+	navigator.entries = make([]*directory.Entry, 0, len(navigator.allEntries))
+	for _, entry := range navigator.allEntries {
+		if (navigator.filterMatch == nil || navigator.filterMatch(entry.Name)) && navigator.oldEnough(entry) &&
+			navigator.listable(entry) {
+			navigator.entries = append(navigator.entries, entry)
+		}
+	}
+	// Duplicates are kept in their groups, rather than sorted.
+	if navigator.listing != duplicatesListing {
+		navigator.sortOrder.Sort(navigator.entries)
+	}
+
+	navigator.treeGuides = nil
+	if navigator.treeListed() {
+		navigator.treeGuides = make(map[*directory.Entry]string)
+		navigator.entries = navigator.listTree(navigator.entries, 0, "")
+	}
+
+	navigator.selectedIndex = 0
+	for index, entry := range navigator.entries {
+		if entry == selectedEntry {
+			navigator.selectedIndex = index
+		}
+	}
+}
+
+// Returns a function that reports whether a name matches the pattern.
+func compileFilter(pattern string) (func(name string) bool, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	// Patterns wrapped in slashes are regular expressions.
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expression, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		return expression.MatchString, nil
+	}
+
+	// Match globs without any wildcards anywhere in the name.
+	if !strings.ContainsAny(pattern, "*?[") {
+		pattern = "*" + pattern + "*"
+	}
+
+	// Check the pattern once, up front, so that
+	// matching itself can't fail later on.
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, errors.New("invalid filter pattern: " + pattern)
+	}
+
+	return func(name string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// Selects the first entry, starting with the selected entry and wrapping around
+// to the beginning of the list, whose name contains the term (ignoring case).
+// The term is remembered for use with SelectNextMatch and SelectPreviousMatch.
+// Returns false, leaving the selection alone, if no entries match.
+func (navigator *Navigator) Search(term string) bool {
+	navigator.searchTerm = term
+
+	return navigator.selectMatch(0, 1)
+}
+
+// Selects the next entry matching the last search term, wrapping around
+// to the beginning of the list if the end of the list has been reached.
+func (navigator *Navigator) SelectNextMatch() {
+	navigator.selectMatch(1, 1)
+}
+
+// Selects the previous entry matching the last search term, wrapping
+// around to the end of the list if the beginning has been reached.
+func (navigator *Navigator) SelectPreviousMatch() {
+	navigator.selectMatch(-1, -1)
+}
+
+// Steps through the entries in the given direction, beginning at the
+// specified offset from the selected entry, and selects the first match.
+func (navigator *Navigator) selectMatch(offset, direction int) bool {
+	entryCount := len(navigator.entries)
+	if navigator.searchTerm == "" || entryCount == 0 {
+		return false
+	}
+
+	term := strings.ToLower(navigator.searchTerm)
+	for step := 0; step < entryCount; step++ {
+		index := navigator.selectedIndex + offset + step*direction
+
+		// Wrap around either end of the list.
+		index = ((index % entryCount) + entryCount) % entryCount
+
+		if strings.Contains(strings.ToLower(navigator.entries[index].Name), term) {
+			navigator.selectedIndex = index
+			return true
+		}
+	}
+
+	return false
+}
+
+// Opens a search, filter or age filter prompt, which is displayed in the status line.
+func (navigator *Navigator) openPrompt(command string) {
+	navigator.prompt = &prompt{command: command, origin: navigator.selectedIndex}
+}
+
+// Updates the open prompt's text. Searches are performed
+// incrementally, moving the selection as the term is typed.
+func (navigator *Navigator) updatePrompt(text string) {
+	if navigator.prompt == nil {
+		return
+	}
+	navigator.prompt.text = text
+
+	if navigator.prompt.command == "Search" {
+		navigator.selectedIndex = navigator.prompt.origin
+		navigator.Search(text)
+	}
+}
+
+// Closes the open prompt, applying its text.
+func (navigator *Navigator) confirmPrompt(text string) error {
+	if navigator.prompt == nil {
+		return nil
+	}
+	command := navigator.prompt.command
+	navigator.updatePrompt(text)
+	navigator.prompt = nil
+
+	switch command {
+	case "Filter":
+		return navigator.SetFilter(text)
+	case "AgeFilter":
+		return navigator.SetAgeFilter(text)
+	}
+
+	return nil
+}
+
+// Closes the open prompt, restoring the selection to where it was when opened.
+func (navigator *Navigator) cancelPrompt() {
+	if navigator.prompt == nil {
+		return
+	}
+	navigator.SelectEntry(navigator.prompt.origin)
+	navigator.prompt = nil
+}
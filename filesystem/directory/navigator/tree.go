@@ -0,0 +1,215 @@
+package navigator
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// Reports whether entries are listed as a tree, with
+// the entries of expanded directories beneath them.
+func (navigator *Navigator) Tree() bool {
+	return navigator.tree
+}
+
+// Toggles listing entries as a tree, in which directories can be expanded to list
+// their entries beneath them (indented, and named using their paths relative to the
+// current directory), so that nested directories' sizes can be compared in place.
+func (navigator *Navigator) ToggleTree() {
+	navigator.tree = !navigator.tree
+	navigator.arrangeEntries()
+}
+
+// Expands the selected directory, listing its entries (and calculating their sizes)
+// beneath it, or collapses it if it's already expanded. Entries other than directories,
+// and those listed when the tree isn't being shown, are left alone.
+func (navigator *Navigator) ToggleSelectedEntryExpanded() error {
+	entry := navigator.SelectedEntry()
+	if entry == nil {
+		return errNoSelection
+	}
+	if !navigator.treeListed() || !entry.IsDirectory {
+		return nil
+	}
+
+	if _, expanded := navigator.expanded[entry.Name]; expanded {
+		navigator.collapse(entry.Name)
+	} else if err := navigator.expand(entry); err != nil {
+		return err
+	}
+	navigator.arrangeEntries()
+
+	return nil
+}
+
+// Reports whether entries are currently being listed as a tree.
+func (navigator *Navigator) treeListed() bool {
+	return navigator.tree && navigator.listing == directoryListing
+}
+
+// Reads the entries of a directory, so that they're listed beneath it.
+func (navigator *Navigator) expand(entry *directory.Entry) error {
+	dirEntries, err := ioutil.ReadDir(navigator.currentPath + "/" + entry.Name)
+	if err != nil {
+		return err
+	}
+
+	children := make([]*directory.Entry, len(dirEntries))
+	for index, dirEntry := range dirEntries {
+		child := newEntry(navigator.currentPath+"/"+entry.Name+"/"+dirEntry.Name(), dirEntry)
+		child.Name = entry.Name + "/" + child.Name
+		children[index] = child
+
+		if child.IsDirectory {
+			navigator.calculateSize(child)
+		}
+	}
+
+	if navigator.expanded == nil {
+		navigator.expanded = make(map[string][]*directory.Entry)
+	}
+	navigator.expanded[entry.Name] = children
+
+	return nil
+}
+
+// Collapses the named directory, along with any expanded directories within it.
+func (navigator *Navigator) collapse(name string) {
+	for expandedName := range navigator.expanded {
+		if expandedName == name || strings.HasPrefix(expandedName, name+"/") {
+			delete(navigator.expanded, expandedName)
+		}
+	}
+}
+
+// Lists the entries (which are siblings, sorted in the order they're to be
+// listed) followed by the entries of those that have been expanded, recording
+// the guide characters that precede their names. The guide is used to indent
+// entries below the given depth, which is zero for the current directory's own.
+func (navigator *Navigator) listTree(entries []*directory.Entry, depth int, guide string) []*directory.Entry {
+	// Leave out excluded and hidden entries, so that the last entry listed is joined up properly.
+	listed := make([]*directory.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if navigator.listable(entry) {
+			listed = append(listed, entry)
+		}
+	}
+	entries = listed
+
+	list := make([]*directory.Entry, 0, len(entries))
+	for index, entry := range entries {
+		last := index == len(entries)-1
+		list = append(list, entry)
+
+		childGuide := guide
+		if depth > 0 {
+			if last {
+				navigator.treeGuides[entry] = guide + "└─ "
+				childGuide += "   "
+			} else {
+				navigator.treeGuides[entry] = guide + "├─ "
+				childGuide += "│  "
+			}
+		}
+
+		if children, expanded := navigator.expanded[entry.Name]; expanded {
+			navigator.sortOrder.Sort(children)
+			list = append(list, navigator.listTree(children, depth+1, childGuide)...)
+		}
+	}
+
+	return list
+}
+
+// Returns the name displayed for the entry: its name, (for entries listed
+// beneath an expanded directory) its base name, indented, (for duplicates)
+// its name, joined to the other copies in its group, or (for sets of files
+// in a breakdown) its name, along with its number of files.
+func (navigator *Navigator) displayName(entry *directory.Entry) string {
+	if navigator.breakdownListed() {
+		return breakdownName(entry)
+	}
+	if guide, nested := navigator.treeGuides[entry]; nested {
+		return guide + path.Base(entry.Name)
+	}
+	if guide, duplicate := navigator.duplicateGuide(entry); duplicate {
+		return guide + entry.Name
+	}
+
+	return entry.Name
+}
+
+// Returns the entries listed alongside the named entry: the directory's own,
+// or those of the expanded directory it belongs to. Reports false if the
+// entry belongs to a directory that isn't expanded.
+func (navigator *Navigator) siblings(name string) ([]*directory.Entry, bool) {
+	parent := path.Dir(name)
+	if !navigator.treeListed() || parent == "." {
+		return navigator.allEntries, true
+	}
+
+	children, expanded := navigator.expanded[parent]
+	return children, expanded
+}
+
+// Replaces the entries listed alongside the named entry.
+func (navigator *Navigator) setSiblings(name string, entries []*directory.Entry) {
+	parent := path.Dir(name)
+	if !navigator.treeListed() || parent == "." {
+		navigator.allEntries = entries
+	} else if _, expanded := navigator.expanded[parent]; expanded {
+		navigator.expanded[parent] = entries
+	}
+}
+
+// Returns the named entry, or nil if it isn't listed.
+func (navigator *Navigator) lookup(name string) *directory.Entry {
+	siblings, _ := navigator.siblings(name)
+	for _, entry := range siblings {
+		if entry.Name == name {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Stops listing an entry that's been removed, deducting its size
+// (and its files) from the directories it was listed beneath.
+func (navigator *Navigator) dropEntry(removedEntry *directory.Entry) {
+	navigator.dropDuplicate(removedEntry)
+
+	siblings, _ := navigator.siblings(removedEntry.Name)
+	for index, entry := range siblings {
+		if entry == removedEntry {
+			navigator.setSiblings(entry.Name, append(siblings[:index], siblings[index+1:]...))
+			break
+		}
+	}
+	navigator.collapse(removedEntry.Name)
+
+	// Excluded entries aren't counted towards the directories they're in.
+	if !navigator.treeListed() || removedEntry.Excluded {
+		return
+	}
+	for parent := path.Dir(removedEntry.Name); parent != "."; parent = path.Dir(parent) {
+		if ancestor := navigator.lookup(parent); ancestor != nil {
+			ancestor.Size -= removedEntry.Size
+			ancestor.Items -= removedEntry.Items + 1
+			ancestor.DiskUsage -= removedEntry.DiskUsage
+			if ancestor.Types != nil {
+				for extension, total := range removedEntry.TypeTotals() {
+					ancestor.Types.Add(extension, -total.Size, -total.Files)
+				}
+				for uid, total := range removedEntry.OwnerTotals() {
+					ancestor.Owners.Add(uid, -total.Size, -total.Files)
+				}
+				for gid, total := range removedEntry.GroupTotals() {
+					ancestor.Groups.Add(gid, -total.Size, -total.Files)
+				}
+			}
+		}
+	}
+}
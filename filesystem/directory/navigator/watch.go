@@ -0,0 +1,109 @@
+package navigator
+
+import (
+	"os"
+	"time"
+
+	"github.com/jmacdonald/purge/filesystem/directory"
+)
+
+// WatchSubtree determines whether changes anywhere beneath the current
+// directory are watched for, rather than only changes to its own entries.
+var WatchSubtree bool
+
+// How long changes are collected for before they're applied, so that a burst
+// of changes is applied (and re-sized) at once. Changes that keep coming are
+// applied at this interval, rather than waiting for them to stop.
+var ChangeDelay = 500 * time.Millisecond
+
+// Starts watching the current directory for changes,
+// replacing the watcher used for the previous one.
+func (navigator *Navigator) startWatching() {
+	navigator.stopWatching()
+
+	navigator.watcher = directory.Watch(navigator.currentPath, WatchSubtree)
+	navigator.watchChanges = navigator.watcher.Changes
+}
+
+// Stops watching the current directory, if it's being watched,
+// forgetting about any changes that haven't been applied yet.
+func (navigator *Navigator) stopWatching() {
+	if navigator.watcher != nil {
+		navigator.watcher.Close()
+		navigator.watcher = nil
+		navigator.watchChanges = nil
+	}
+
+	navigator.changes = nil
+	navigator.changeTimer = nil
+}
+
+// Notes that the named entry has changed, to be applied along
+// with any other changes reported within ChangeDelay of the first.
+func (navigator *Navigator) recordChange(name string) {
+	if navigator.changes == nil {
+		navigator.changes = make(map[string]bool)
+	}
+	navigator.changes[name] = true
+	if navigator.changeTimer == nil {
+		navigator.changeTimer = time.After(ChangeDelay)
+	}
+}
+
+// Brings the changed entries up to date: adding those that have been created,
+// removing those that no longer exist and refreshing the rest, re-calculating
+// the sizes of any changed directories.
+func (navigator *Navigator) applyChanges() {
+	changes := navigator.changes
+	navigator.changes = nil
+	navigator.changeTimer = nil
+
+	for name := range changes {
+		navigator.applyChange(name)
+	}
+
+	// Re-filter (and re-sort) the entries, keeping the same entry selected.
+	navigator.applyFilter()
+}
+
+// Brings the named entry up to date with the filesystem. Entries belonging to
+// directories that aren't listed (i.e. collapsed directories in a tree) are ignored.
+func (navigator *Navigator) applyChange(name string) {
+	siblings, listed := navigator.siblings(name)
+	if !listed {
+		return
+	}
+	entry := navigator.lookup(name)
+
+	info, err := os.Lstat(navigator.currentPath + "/" + name)
+	if err != nil {
+		// The entry no longer exists.
+		if entry != nil {
+			navigator.dropEntry(entry)
+		}
+		return
+	}
+
+	fresh := newEntry(navigator.currentPath+"/"+name, info)
+	fresh.Name = name
+	if entry == nil {
+		navigator.setSiblings(name, append(siblings, fresh))
+		if fresh.IsDirectory {
+			navigator.calculateSize(fresh)
+		}
+		return
+	}
+
+	// Update the existing entry in place (so that pending size calculations still find it),
+	// keeping a directory's current size on display until it's been re-calculated.
+	if fresh.IsDirectory && entry.IsDirectory {
+		fresh.Size, fresh.Items, fresh.DiskUsage = entry.Size, entry.Items, entry.DiskUsage
+		fresh.LastModified, fresh.LastAccessed = entry.LastModified, entry.LastAccessed
+		fresh.Types, fresh.Owners, fresh.Groups = entry.Types, entry.Owners, entry.Groups
+		fresh.SizeCalculated, fresh.Provisional = entry.SizeCalculated, entry.Provisional
+	}
+	*entry = *fresh
+	if entry.IsDirectory {
+		navigator.calculateSize(entry)
+	}
+}
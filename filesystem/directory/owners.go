@@ -0,0 +1,57 @@
+package directory
+
+import (
+	"os"
+	"syscall"
+)
+
+// Totals of the files within a directory (at any
+// depth), keyed by their owner's user or group ID.
+type IDTotals map[uint32]*FileTotal
+
+// Adds to the total for files owned by the given ID. The size and file
+// count can be negative (e.g. when files are removed); the total is
+// dropped once it no longer includes any files.
+func (totals IDTotals) Add(id uint32, size, files int64) {
+	total, ok := totals[id]
+	if !ok {
+		total = &FileTotal{}
+		totals[id] = total
+	}
+	total.Size += size
+	total.Files += files
+
+	if total.Files <= 0 {
+		delete(totals, id)
+	}
+}
+
+// Returns the IDs of the user and group that own the file described by info,
+// or zero if they aren't available.
+func Ownership(info os.FileInfo) (uid, gid uint32) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return stat.Uid, stat.Gid
+	}
+	return 0, 0
+}
+
+// Returns the totals of the files within the entry, by owner: its own size,
+// for files, or the totals found when its size was calculated, for
+// directories (which are nil until then).
+func (entry *Entry) OwnerTotals() IDTotals {
+	if entry.IsDirectory {
+		return entry.Owners
+	}
+
+	return IDTotals{entry.Uid: &FileTotal{Size: entry.Size, Files: 1}}
+}
+
+// Returns the totals of the files within the entry, by group,
+// in the same way as OwnerTotals.
+func (entry *Entry) GroupTotals() IDTotals {
+	if entry.IsDirectory {
+		return entry.Groups
+	}
+
+	return IDTotals{entry.Gid: &FileTotal{Size: entry.Size, Files: 1}}
+}
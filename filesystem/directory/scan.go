@@ -0,0 +1,100 @@
+package directory
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+Scan tracks the progress of one or more size calculations, counting the
+files (i.e. anything but directories) visited and bytes found (or hashed) so far, along with the
+directory most recently entered. Calculations update it as they go, so that progress
+can be reported while they're still underway. It's safe for concurrent use.
+*/
+type Scan struct {
+	files   int64
+	bytes   int64
+	hashed  int64
+	current string
+	started time.Time
+	mutex   sync.Mutex
+}
+
+// NewScan returns a scan that's just been started.
+func NewScan() *Scan {
+	return &Scan{started: time.Now()}
+}
+
+// Files returns the number of files visited so far.
+func (scan *Scan) Files() int64 {
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	return scan.files
+}
+
+// Bytes returns the total size of the files visited so far.
+func (scan *Scan) Bytes() int64 {
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	return scan.bytes
+}
+
+// Hashed returns the number of bytes hashed so far, when files' contents are compared.
+func (scan *Scan) Hashed() int64 {
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	return scan.hashed
+}
+
+// Current returns the path of the directory most recently entered
+// (or, once files' contents are being compared, the file being hashed).
+func (scan *Scan) Current() string {
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	return scan.current
+}
+
+// Throughput returns the average number of bytes counted per second.
+func (scan *Scan) Throughput() float64 {
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	elapsed := time.Since(scan.started).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(scan.bytes) / elapsed
+}
+
+// Records a visit to a directory, containing the
+// specified number of files and bytes.
+func (scan *Scan) visit(path string, files, bytes int64) {
+	if scan == nil {
+		return
+	}
+
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	scan.current = path
+	scan.files += files
+	scan.bytes += bytes
+}
+
+// Records that the specified number of bytes of the file at the given path have been hashed.
+func (scan *Scan) hash(path string, bytes int64) {
+	if scan == nil {
+		return
+	}
+
+	scan.mutex.Lock()
+	defer scan.mutex.Unlock()
+
+	scan.current = path
+	scan.hashed += bytes
+}
@@ -0,0 +1,97 @@
+package directory
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Structure totalling a set of files (e.g. those of a particular type).
+type FileTotal struct {
+	Size  int64
+	Files int64
+}
+
+// Totals of the files within a directory (at any depth),
+// keyed by their extension (see Extension).
+type TypeTotals map[string]*FileTotal
+
+// Adds to the total for files with the given extension. The size and file
+// count can be negative (e.g. when files are removed); the total is
+// dropped once it no longer includes any files.
+func (totals TypeTotals) Add(extension string, size, files int64) {
+	total, ok := totals[extension]
+	if !ok {
+		total = &FileTotal{}
+		totals[extension] = total
+	}
+	total.Size += size
+	total.Files += files
+
+	if total.Files <= 0 {
+		delete(totals, extension)
+	}
+}
+
+// Returns the lowercase extension of the named file, including
+// the leading dot, or an empty string if it doesn't have one.
+func Extension(name string) string {
+	return strings.ToLower(filepath.Ext(name))
+}
+
+// The category used for extensions that aren't listed in Categories.
+const OtherCategory = "other"
+
+// Broad categories of files, keyed by extension, used to
+// break down the space used by files of similar types.
+var Categories = map[string]string{
+	".7z": "archives", ".bz2": "archives", ".deb": "archives", ".dmg": "archives",
+	".gz": "archives", ".iso": "archives", ".jar": "archives", ".rar": "archives",
+	".rpm": "archives", ".tar": "archives", ".tgz": "archives", ".xz": "archives",
+	".zip": "archives", ".zst": "archives",
+
+	".aac": "audio", ".flac": "audio", ".m4a": "audio", ".mp3": "audio",
+	".ogg": "audio", ".opus": "audio", ".wav": "audio",
+
+	".avi": "video", ".m4v": "video", ".mkv": "video", ".mov": "video",
+	".mp4": "video", ".mpg": "video", ".webm": "video", ".wmv": "video",
+
+	".bmp": "images", ".gif": "images", ".heic": "images", ".jpeg": "images",
+	".jpg": "images", ".png": "images", ".psd": "images", ".raw": "images",
+	".svg": "images", ".tif": "images", ".tiff": "images", ".webp": "images",
+
+	".csv": "documents", ".doc": "documents", ".docx": "documents", ".epub": "documents",
+	".md": "documents", ".odt": "documents", ".pdf": "documents", ".ppt": "documents",
+	".pptx": "documents", ".txt": "documents", ".xls": "documents", ".xlsx": "documents",
+
+	".c": "code", ".cc": "code", ".cpp": "code", ".go": "code", ".h": "code",
+	".java": "code", ".js": "code", ".py": "code", ".rb": "code", ".rs": "code",
+	".sh": "code", ".ts": "code",
+
+	".a": "objects", ".class": "objects", ".dll": "objects", ".dylib": "objects",
+	".exe": "objects", ".o": "objects", ".obj": "objects", ".pyc": "objects",
+	".so": "objects", ".wasm": "objects",
+
+	".log": "logs", ".out": "logs",
+
+	".bak": "backups", ".db": "databases", ".sqlite": "databases",
+}
+
+// Returns the category of files with the given
+// extension, or OtherCategory if it isn't known.
+func Category(extension string) string {
+	if category, ok := Categories[extension]; ok {
+		return category
+	}
+	return OtherCategory
+}
+
+// Returns the totals of the files within the entry, by type: its own
+// size, for files, or the totals found when its size was calculated,
+// for directories (which are nil until then).
+func (entry *Entry) TypeTotals() TypeTotals {
+	if entry.IsDirectory {
+		return entry.Types
+	}
+
+	return TypeTotals{Extension(entry.Name): &FileTotal{Size: entry.Size, Files: 1}}
+}
@@ -0,0 +1,121 @@
+package directory
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// How often directories are checked for changes when they can't be watched.
+var PollInterval = 2 * time.Second
+
+/*
+Watcher reports changes to a directory, sending the name of each of its
+entries that's been added, removed or modified on Changes. When watching
+the directory's subtree, changes anywhere within an entry are reported
+using the entry's name. A single change may be reported more than once.
+
+Directories are watched using inotify on Linux. Elsewhere, if inotify isn't
+available, or on network and FUSE filesystems (where inotify accepts watches
+but doesn't report changes made by other hosts or by the filesystem itself),
+the directory is polled instead, which only notices changes to the
+directory's own entries (including the modification times of subdirectories,
+which change as entries are added to or removed from them).
+*/
+type Watcher struct {
+	Changes <-chan string
+
+	changes   chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Watch starts watching the directory at the given path,
+// including its subtree if requested, until it's closed.
+func Watch(path string, subtree bool) *Watcher {
+	watcher := newWatcher()
+	if err := watcher.watch(path, subtree); err != nil {
+		watcher.startPolling(path)
+	}
+
+	return watcher
+}
+
+// Returns a watcher that isn't watching anything yet.
+func newWatcher() *Watcher {
+	watcher := &Watcher{changes: make(chan string, 64), done: make(chan struct{})}
+	watcher.Changes = watcher.changes
+
+	return watcher
+}
+
+// Close stops watching the directory. Changes isn't closed,
+// but no more changes will be sent on it.
+func (watcher *Watcher) Close() {
+	watcher.closeOnce.Do(func() {
+		close(watcher.done)
+	})
+}
+
+// Reports a change, returning false if the watcher has been closed.
+func (watcher *Watcher) send(name string) bool {
+	select {
+	case watcher.changes <- name:
+		return true
+	case <-watcher.done:
+		return false
+	}
+}
+
+// Starts polling the directory in the background, recording the state of
+// its entries right away, so that any changes made from now on are noticed.
+func (watcher *Watcher) startPolling(path string) {
+	go watcher.poll(path, snapshot(path), time.NewTicker(PollInterval))
+}
+
+// Periodically compares the directory's entries to their previous
+// state, reporting those that have been added, removed or modified.
+func (watcher *Watcher) poll(path string, previous map[string]entryState, ticker *time.Ticker) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-watcher.done:
+			return
+		case <-ticker.C:
+		}
+
+		current := snapshot(path)
+		for name, state := range current {
+			if previousState, ok := previous[name]; (!ok || previousState != state) && !watcher.send(name) {
+				return
+			}
+		}
+		for name := range previous {
+			if _, ok := current[name]; !ok && !watcher.send(name) {
+				return
+			}
+		}
+		previous = current
+	}
+}
+
+// Structure used to detect changes to an entry when polling.
+type entryState struct {
+	size    int64
+	modTime int64
+	mode    os.FileMode
+}
+
+// Returns the state of each of the directory's entries, keyed by name.
+func snapshot(path string) map[string]entryState {
+	entries, _ := ioutil.ReadDir(path)
+
+	states := make(map[string]entryState, len(entries))
+	for _, info := range entries {
+		states[info.Name()] = entryState{info.Size(), info.ModTime().UnixNano(), info.Mode()}
+	}
+
+	return states
+}
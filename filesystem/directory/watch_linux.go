@@ -0,0 +1,179 @@
+package directory
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"syscall"
+	"unsafe"
+)
+
+// The inotify events that indicate an entry has changed.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// How long to wait for events (in milliseconds) before checking whether the watcher has been closed.
+const inotifyTimeout = 250
+
+// Filesystems, keyed by their statfs magic numbers, on which inotify can't be
+// relied on, since changes made other than through the local kernel aren't reported.
+var remoteFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+	0x01021997: "9p",
+	0x00c36400: "ceph",
+	0x0bd00bd0: "lustre",
+	0x47504653: "gpfs",
+	0x5346414f: "afs",
+	0x6b414653: "afs",
+}
+
+// Returns the name of the remote (or FUSE) filesystem that the directory
+// at the given path is on, or an empty string if it's on a local one.
+func remoteFilesystem(path string) string {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return ""
+	}
+
+	return remoteFilesystems[uint32(stat.Type)]
+}
+
+// Structure describing an inotify watch: the path of the watched directory, and the
+// name of the entry it belongs to (or an empty string for the top-level directory).
+type inotifyWatch struct {
+	path  string
+	entry string
+}
+
+// Watches the directory (and its subtree, if requested) using inotify,
+// unless it's on a filesystem that inotify doesn't work for.
+func (watcher *Watcher) watch(path string, subtree bool) error {
+	if filesystem := remoteFilesystem(path); filesystem != "" {
+		return errors.New("directory: inotify doesn't report changes on " + filesystem + " filesystems")
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+
+	// Wait for events using epoll, so that we can stop waiting periodically.
+	epoll, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		syscall.Close(fd)
+		return err
+	}
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+	if err = syscall.EpollCtl(epoll, syscall.EPOLL_CTL_ADD, fd, &event); err != nil {
+		syscall.Close(epoll)
+		syscall.Close(fd)
+		return err
+	}
+
+	watches := make(map[int32]inotifyWatch)
+	wd, err := syscall.InotifyAddWatch(fd, path, inotifyMask)
+	if err != nil {
+		syscall.Close(epoll)
+		syscall.Close(fd)
+		return err
+	}
+	watches[int32(wd)] = inotifyWatch{path: path}
+
+	if subtree {
+		entries, _ := ioutil.ReadDir(path)
+		for _, info := range entries {
+			if info.IsDir() {
+				addInotifyWatches(fd, watches, path+"/"+info.Name(), info.Name())
+			}
+		}
+	}
+
+	go watcher.readInotify(fd, epoll, watches, subtree)
+
+	return nil
+}
+
+// Watches the directory at the given path and its subdirectories, recording
+// them as belonging to the specified entry. Directories that can't be
+// watched (e.g. once the limit on watches has been reached) are skipped.
+func addInotifyWatches(fd int, watches map[int32]inotifyWatch, path, entry string) {
+	wd, err := syscall.InotifyAddWatch(fd, path, inotifyMask)
+	if err != nil {
+		return
+	}
+	watches[int32(wd)] = inotifyWatch{path: path, entry: entry}
+
+	entries, _ := ioutil.ReadDir(path)
+	for _, info := range entries {
+		if info.IsDir() {
+			addInotifyWatches(fd, watches, path+"/"+info.Name(), entry)
+		}
+	}
+}
+
+// Reads inotify events until the watcher is closed, reporting the entries they belong to.
+func (watcher *Watcher) readInotify(fd, epoll int, watches map[int32]inotifyWatch, subtree bool) {
+	defer syscall.Close(fd)
+	defer syscall.Close(epoll)
+
+	events := make([]syscall.EpollEvent, 1)
+	buffer := make([]byte, 64*1024)
+	for {
+		select {
+		case <-watcher.done:
+			return
+		default:
+		}
+
+		if ready, err := syscall.EpollWait(epoll, events, inotifyTimeout); err != nil && err != syscall.EINTR {
+			return
+		} else if ready < 1 {
+			continue
+		}
+
+		length, err := syscall.Read(fd, buffer)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		} else if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= length; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buffer[nameStart:nameStart+int(event.Len)], "\x00"))
+			offset = nameStart + int(event.Len)
+
+			watch, ok := watches[event.Wd]
+			if !ok {
+				continue
+			}
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(watches, event.Wd)
+				continue
+			}
+
+			// Changes within the subtree are reported using the entry they belong to.
+			entry := watch.entry
+			if entry == "" {
+				entry = name
+			}
+			if entry == "" {
+				continue
+			}
+
+			// Start watching new subdirectories, too.
+			if subtree && event.Mask&syscall.IN_CREATE != 0 && event.Mask&syscall.IN_ISDIR != 0 {
+				addInotifyWatches(fd, watches, watch.path+"/"+name, entry)
+			}
+
+			if !watcher.send(entry) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package directory
+
+import "errors"
+
+// Directories can only be polled on this platform.
+func (watcher *Watcher) watch(path string, subtree bool) error {
+	return errors.New("directory: watching isn't supported on this platform")
+}
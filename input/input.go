@@ -4,20 +4,67 @@ package input
 
 import (
 	"io"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
-// Define a map to translate keystrokes into commands.
-var Map = map[rune]string {
-	'j': "SelectNextEntry",
-	'b': "SelectLastEntry",
-	'k': "SelectPreviousEntry",
-	't': "SelectFirstEntry",
-	's': "SortEntries",
-	'\r': "IntoSelectedEntry",
-	'h': "ToParentDirectory",
-	'x': "RemoveSelectedEntry",
-	'q': "Quit",
+// Define a map to translate keystroke sequences into commands.
+var Map = map[string]string{
+	"j":    "SelectNextEntry",
+	"b":    "SelectLastEntry",
+	"G":    "SelectLastEntry",
+	"k":    "SelectPreviousEntry",
+	"t":    "SelectFirstEntry",
+	"gg":   "SelectFirstEntry",
+	"\x04": "SelectNextHalfPage",
+	"\x15": "SelectPreviousHalfPage",
+	"s":    "SortEntries",
+	"S":    "ReverseSortOrder",
+	"d":    "ToggleDirectoriesFirst",
+	"a":    "ToggleAutoSort",
+	"\r":   "IntoSelectedEntry",
+	"h":    "ToParentDirectory",
+	"x":    "RemoveSelectedEntry",
+	"r":    "Refresh",
+	"R":    "RescanSelectedEntry",
+	"L":    "ToggleLargestFiles",
+	"T":    "ToggleTree",
+	"e":    "ToggleExtensions",
+	"E":    "ToggleCategories",
+	"U":    "ToggleOwners",
+	"O":    "ToggleGroups",
+	".":    "ToggleHidden",
+	"I":    "ToggleExcluded",
+	"D":    "ToggleDuplicates",
+	"X":    "RemoveOtherDuplicates",
+	"H":    "LinkDuplicates",
+	" ":    "ToggleSelectedEntryExpanded",
+	"/":    "Search",
+	"n":    "SelectNextMatch",
+	"N":    "SelectPreviousMatch",
+	"f":    "Filter",
+	"o":    "AgeFilter",
+	"?":    "ShowHelp",
+	"q":    "Quit",
+}
+
+// Commands that open a prompt; keystrokes that follow them are collected
+// as text and relayed using the UpdatePrompt, ConfirmPrompt and
+// CancelPrompt commands, instead of being mapped.
+var Prompts = map[string]bool{
+	"Search":    true,
+	"Filter":    true,
+	"AgeFilter": true,
+}
+
+// Command pairs a navigator command with the count typed before it.
+// A count of zero means that no count was provided. Argument carries
+// the prompt text for prompt-related commands.
+type Command struct {
+	Name     string
+	Count    int
+	Argument string
 }
 
 // Navigator defines the interface expected by the input package,
@@ -27,10 +74,132 @@ type Navigator interface {
 	SelectLastEntry()
 	SelectPreviousEntry()
 	SelectFirstEntry()
+	SelectEntry(index int)
+	SelectEntryOffset(offset int)
+	SelectNextHalfPage(pageSize int)
+	SelectPreviousHalfPage(pageSize int)
 	SortEntries()
+	ReverseSortOrder()
+	ToggleDirectoriesFirst()
+	ToggleAutoSort()
+	Search(term string) bool
+	SelectNextMatch()
+	SelectPreviousMatch()
+	SetFilter(pattern string) error
+	SetAgeFilter(age string) error
 	IntoSelectedEntry() error
 	ToParentDirectory() error
 	RemoveSelectedEntry() error
+	Refresh()
+	RescanSelectedEntry() error
+	ToggleLargestFiles()
+	ToggleTree()
+	ToggleSelectedEntryExpanded() error
+	ToggleExtensions()
+	ToggleCategories()
+	ToggleOwners()
+	ToggleGroups()
+	ToggleHidden()
+	ToggleExcluded()
+	ToggleDuplicates()
+	RemoveOtherDuplicates() (int64, error)
+	LinkDuplicates() (int64, error)
+}
+
+// Parser turns individual keystrokes into commands, accumulating
+// count prefixes (e.g. "5j") and multi-key sequences (e.g. "gg").
+type Parser struct {
+	count       int
+	sequence    string
+	prompting   bool
+	text        []rune
+	showingHelp bool
+}
+
+// Parse feeds a single keystroke to the parser. If the keystroke completes
+// a mapped sequence, the corresponding command is returned with ok set to true.
+func (parser *Parser) Parse(character rune) (command Command, ok bool) {
+	if parser.prompting {
+		return parser.parsePrompt(character)
+	}
+
+	// Any keystroke dismisses the help overlay.
+	if parser.showingHelp {
+		parser.showingHelp = false
+		return Command{Name: "HideHelp"}, true
+	}
+
+	// Digits build up the count prefix. A leading zero isn't
+	// treated as a count, leaving it free to be mapped to a command.
+	if parser.sequence == "" && character >= '0' && character <= '9' &&
+		(character != '0' || parser.count > 0) {
+		parser.count = parser.count*10 + int(character-'0')
+		return
+	}
+
+	parser.sequence += string(character)
+	if name, mapped := Map[parser.sequence]; mapped {
+		command, ok = Command{Name: name, Count: parser.count}, true
+		parser.Reset()
+		parser.prompting = Prompts[name]
+		parser.showingHelp = name == "ShowHelp"
+		return
+	}
+
+	// Discard the pending keystrokes (and count) if they
+	// can't possibly lead to a mapped sequence.
+	if !isPrefix(parser.sequence) {
+		parser.Reset()
+	}
+	return
+}
+
+// Handles a keystroke typed into an open prompt.
+func (parser *Parser) parsePrompt(character rune) (command Command, ok bool) {
+	switch {
+	case character == '\r':
+		command = Command{Name: "ConfirmPrompt", Argument: string(parser.text)}
+		parser.Reset()
+	case character == '\x1b':
+		command = Command{Name: "CancelPrompt"}
+		parser.Reset()
+	case character == '\x7f' || character == '\b':
+		// Backspacing out of an empty prompt closes it.
+		if len(parser.text) == 0 {
+			command = Command{Name: "CancelPrompt"}
+			parser.Reset()
+		} else {
+			parser.text = parser.text[:len(parser.text)-1]
+			command = Command{Name: "UpdatePrompt", Argument: string(parser.text)}
+		}
+	case unicode.IsPrint(character):
+		parser.text = append(parser.text, character)
+		command = Command{Name: "UpdatePrompt", Argument: string(parser.text)}
+	default:
+		// Ignore any other control characters.
+		return
+	}
+
+	return command, true
+}
+
+// Reset discards any pending count, keystrokes and prompt text.
+func (parser *Parser) Reset() {
+	parser.count = 0
+	parser.sequence = ""
+	parser.prompting = false
+	parser.text = nil
+	parser.showingHelp = false
+}
+
+// Reports whether the sequence is the beginning of a longer mapped sequence.
+func isPrefix(sequence string) bool {
+	for mapped := range Map {
+		if len(mapped) > len(sequence) && strings.HasPrefix(mapped, sequence) {
+			return true
+		}
+	}
+	return false
 }
 
 // Reads and returns a single rune from the provided source.
@@ -38,4 +38,235 @@ var _ = Describe("Input", func() {
 			})
 		})
 	})
+
+	Describe("Parser", func() {
+		var parser *Parser
+		var keystrokes string
+		var commands []Command
+
+		BeforeEach(func() {
+			parser = new(Parser)
+		})
+
+		JustBeforeEach(func() {
+			commands = nil
+			for _, character := range keystrokes {
+				if command, ok := parser.Parse(character); ok {
+					commands = append(commands, command)
+				}
+			}
+		})
+
+		Context("a single mapped keystroke", func() {
+			BeforeEach(func() {
+				keystrokes = "j"
+			})
+
+			It("returns the mapped command without a count", func() {
+				Expect(commands).To(Equal([]Command{{Name: "SelectNextEntry"}}))
+			})
+		})
+
+		Context("a keystroke preceded by a count", func() {
+			BeforeEach(func() {
+				keystrokes = "15k"
+			})
+
+			It("returns the mapped command with the count", func() {
+				Expect(commands).To(Equal([]Command{{Name: "SelectPreviousEntry", Count: 15}}))
+			})
+		})
+
+		Context("a count starting with zero", func() {
+			BeforeEach(func() {
+				keystrokes = "03h"
+			})
+
+			It("ignores the leading zero", func() {
+				Expect(commands).To(Equal([]Command{{Name: "ToParentDirectory", Count: 3}}))
+			})
+		})
+
+		Context("a multi-key sequence", func() {
+			BeforeEach(func() {
+				keystrokes = "gg"
+			})
+
+			It("returns the mapped command once the sequence is complete", func() {
+				Expect(commands).To(Equal([]Command{{Name: "SelectFirstEntry"}}))
+			})
+		})
+
+		Context("an incomplete multi-key sequence", func() {
+			BeforeEach(func() {
+				keystrokes = "g"
+			})
+
+			It("does not return a command", func() {
+				Expect(commands).To(BeEmpty())
+			})
+		})
+
+		Context("an unmapped sequence followed by a mapped keystroke", func() {
+			BeforeEach(func() {
+				keystrokes = "4gzj"
+			})
+
+			It("discards the unmapped sequence and its count", func() {
+				Expect(commands).To(Equal([]Command{{Name: "SelectNextEntry"}}))
+			})
+		})
+
+		Context("a control character", func() {
+			BeforeEach(func() {
+				keystrokes = "2\x04"
+			})
+
+			It("returns the mapped command with the count", func() {
+				Expect(commands).To(Equal([]Command{{Name: "SelectNextHalfPage", Count: 2}}))
+			})
+		})
+
+		Context("a prompt command followed by text", func() {
+			BeforeEach(func() {
+				keystrokes = "/ab\x7fc\r"
+			})
+
+			It("relays the prompt text as it's typed and confirmed", func() {
+				Expect(commands).To(Equal([]Command{
+					{Name: "Search"},
+					{Name: "UpdatePrompt", Argument: "a"},
+					{Name: "UpdatePrompt", Argument: "ab"},
+					{Name: "UpdatePrompt", Argument: "a"},
+					{Name: "UpdatePrompt", Argument: "ac"},
+					{Name: "ConfirmPrompt", Argument: "ac"},
+				}))
+			})
+		})
+
+		Context("a prompt that is cancelled", func() {
+			BeforeEach(func() {
+				keystrokes = "fj\x1bj"
+			})
+
+			It("maps keystrokes again after cancelling", func() {
+				Expect(commands).To(Equal([]Command{
+					{Name: "Filter"},
+					{Name: "UpdatePrompt", Argument: "j"},
+					{Name: "CancelPrompt"},
+					{Name: "SelectNextEntry"},
+				}))
+			})
+		})
+
+		Context("the help overlay is shown", func() {
+			BeforeEach(func() {
+				keystrokes = "?zj"
+			})
+
+			It("dismisses it with the next keystroke, whatever it is", func() {
+				Expect(commands).To(Equal([]Command{
+					{Name: "ShowHelp"},
+					{Name: "HideHelp"},
+					{Name: "SelectNextEntry"},
+				}))
+			})
+		})
+	})
+
+	Describe("KeyName", func() {
+		It("returns printable characters as-is", func() {
+			Expect(KeyName("gg")).To(Equal("gg"))
+		})
+
+		It("names control characters", func() {
+			Expect(KeyName("\x04")).To(Equal("<C-d>"))
+		})
+
+		It("names special keys", func() {
+			Expect(KeyName("\r")).To(Equal("<Enter>"))
+		})
+	})
+
+	Describe("ParseKeyName", func() {
+		It("parses printable characters", func() {
+			Expect(ParseKeyName("gg")).To(Equal("gg"))
+		})
+
+		It("parses control characters, ignoring case", func() {
+			Expect(ParseKeyName("<C-D>")).To(Equal("\x04"))
+		})
+
+		It("parses special keys mixed with printable characters", func() {
+			Expect(ParseKeyName("g<Space>")).To(Equal("g "))
+		})
+
+		It("returns an error for unknown special keys", func() {
+			_, err := ParseKeyName("<Hyper>")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("Bind", func() {
+		var originalMap map[string]string
+		var overrides map[string]string
+		var err error
+
+		BeforeEach(func() {
+			originalMap = make(map[string]string)
+			for sequence, command := range Map {
+				originalMap[sequence] = command
+			}
+		})
+
+		AfterEach(func() {
+			Map = originalMap
+		})
+
+		JustBeforeEach(func() {
+			err = Bind(overrides)
+		})
+
+		Context("overrides are valid", func() {
+			BeforeEach(func() {
+				overrides = map[string]string{"<C-n>": "SelectNextEntry", "x": ""}
+			})
+
+			It("adds new mappings", func() {
+				Expect(Map["\x0e"]).To(Equal("SelectNextEntry"))
+			})
+
+			It("removes mappings set to an empty command", func() {
+				Expect(Map).ToNot(HaveKey("x"))
+			})
+
+			It("includes the overrides in the bindings", func() {
+				Expect(Bindings()[0].Keys).To(Equal([]string{"<C-n>", "j"}))
+			})
+
+			It("omits unbound commands from the bindings", func() {
+				for _, binding := range Bindings() {
+					Expect(binding.Command).ToNot(Equal("RemoveSelectedEntry"))
+				}
+			})
+		})
+
+		Context("an override refers to an unknown command", func() {
+			BeforeEach(func() {
+				overrides = map[string]string{"z": "Explode"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).ToNot(BeNil())
+			})
+		})
+	})
+
+	Describe("Help", func() {
+		It("describes every mapped command", func() {
+			for _, command := range Map {
+				Expect(describe(command)).ToNot(BeEmpty(), command)
+			}
+		})
+	})
 })
@@ -0,0 +1,179 @@
+package input
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Structure describing a command that can be mapped to keys.
+type HelpEntry struct {
+	Command     string
+	Description string
+}
+
+// Describes each command that can be mapped to keys,
+// in the order they're listed in the help overlay.
+var Help = []HelpEntry{
+	{"SelectNextEntry", "Select the next entry"},
+	{"SelectPreviousEntry", "Select the previous entry"},
+	{"SelectFirstEntry", "Select the first entry (or the entry numbered by a count)"},
+	{"SelectLastEntry", "Select the last entry (or the entry numbered by a count)"},
+	{"SelectNextHalfPage", "Move down half a page"},
+	{"SelectPreviousHalfPage", "Move up half a page"},
+	{"IntoSelectedEntry", "Open the selected directory (or list the largest files in a breakdown)"},
+	{"ToParentDirectory", "Go up to the parent directory"},
+	{"RemoveSelectedEntry", "Delete the selected entry"},
+	{"Refresh", "Re-read the current directory"},
+	{"RescanSelectedEntry", "Re-calculate the selected entry's size"},
+	{"Search", "Search the current directory"},
+	{"SelectNextMatch", "Select the next search match"},
+	{"SelectPreviousMatch", "Select the previous search match"},
+	{"Filter", "Filter entries using a glob or /regex/"},
+	{"AgeFilter", "Hide entries used within an age (e.g. 180d)"},
+	{"ToggleLargestFiles", "Toggle listing the largest files below this directory"},
+	{"ToggleTree", "Toggle listing entries as a tree"},
+	{"ToggleSelectedEntryExpanded", "Expand or collapse the selected directory in the tree"},
+	{"ToggleExtensions", "Toggle a breakdown of space by file extension"},
+	{"ToggleCategories", "Toggle a breakdown of space by file category"},
+	{"ToggleOwners", "Toggle a breakdown of space by owner"},
+	{"ToggleGroups", "Toggle a breakdown of space by group"},
+	{"ToggleHidden", "Toggle listing hidden entries (dotfiles)"},
+	{"ToggleExcluded", "Toggle listing entries excluded from scans"},
+	{"ToggleDuplicates", "Toggle listing duplicate files below this directory"},
+	{"RemoveOtherDuplicates", "Delete the other copies of the selected duplicate"},
+	{"LinkDuplicates", "Replace the other copies of the selected duplicate with hard links"},
+	{"SortEntries", "Cycle through sort modes"},
+	{"ReverseSortOrder", "Reverse the sort order"},
+	{"ToggleDirectoriesFirst", "Toggle listing directories first"},
+	{"ToggleAutoSort", "Toggle re-sorting as sizes are calculated"},
+	{"ShowHelp", "Show this help"},
+	{"Quit", "Quit"},
+}
+
+// Structure pairing a command with the keys mapped to it.
+type Binding struct {
+	Keys []string
+	HelpEntry
+}
+
+// Names used to display and configure special keys.
+var keyNames = map[rune]string{
+	'\r':   "<Enter>",
+	'\x1b': "<Esc>",
+	'\t':   "<Tab>",
+	' ':    "<Space>",
+	'\x7f': "<BS>",
+	'<':    "<lt>",
+}
+
+// Returns a readable name for a key sequence, using Vim-style
+// names for special keys (e.g. "<C-d>" for Ctrl-d).
+func KeyName(sequence string) string {
+	var name string
+	for _, character := range sequence {
+		if special, ok := keyNames[character]; ok {
+			name += special
+		} else if character > 0 && character < ' ' {
+			name += fmt.Sprintf("<C-%c>", character+'a'-1)
+		} else {
+			name += string(character)
+		}
+	}
+
+	return name
+}
+
+// Converts a key sequence name, as produced by KeyName,
+// back into the sequence of characters it represents.
+func ParseKeyName(name string) (string, error) {
+	var sequence string
+	for len(name) > 0 {
+		end := strings.Index(name, ">")
+		if name[0] != '<' || end < 0 {
+			sequence += name[:1]
+			name = name[1:]
+			continue
+		}
+
+		special := strings.ToLower(name[:end+1])
+		name = name[end+1:]
+
+		if len(special) == 5 && strings.HasPrefix(special, "<c-") &&
+			special[3] >= 'a' && special[3] <= 'z' {
+			sequence += string(rune(special[3] - 'a' + 1))
+			continue
+		}
+
+		found := false
+		for character, specialName := range keyNames {
+			if strings.ToLower(specialName) == special {
+				sequence += string(character)
+				found = true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("input: unknown key %s", special)
+		}
+	}
+
+	if sequence == "" {
+		return "", fmt.Errorf("input: empty key sequence")
+	}
+
+	return sequence, nil
+}
+
+// Applies key map overrides, where keys are sequence names (as accepted by
+// ParseKeyName) and values are command names. Mapping a key sequence to an
+// empty string removes its mapping.
+func Bind(overrides map[string]string) error {
+	for name, command := range overrides {
+		sequence, err := ParseKeyName(name)
+		if err != nil {
+			return err
+		}
+
+		if command == "" {
+			delete(Map, sequence)
+		} else if describe(command) == "" {
+			return fmt.Errorf("input: unknown command %s mapped to %s", command, name)
+		} else {
+			Map[sequence] = command
+		}
+	}
+
+	return nil
+}
+
+// Returns the commands described in Help that are mapped
+// to keys, along with the (sorted) names of those keys.
+func Bindings() []Binding {
+	var bindings []Binding
+	for _, entry := range Help {
+		var keys []string
+		for sequence, command := range Map {
+			if command == entry.Command {
+				keys = append(keys, KeyName(sequence))
+			}
+		}
+
+		if len(keys) > 0 {
+			sort.Strings(keys)
+			bindings = append(bindings, Binding{Keys: keys, HelpEntry: entry})
+		}
+	}
+
+	return bindings
+}
+
+// Returns the description of a command, or an empty string for unknown commands.
+func describe(command string) string {
+	for _, entry := range Help {
+		if entry.Command == command {
+			return entry.Description
+		}
+	}
+
+	return ""
+}
@@ -1,24 +1,49 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
-	"fmt"
+	"strings"
 
+	"github.com/jmacdonald/purge/audit"
+	"github.com/jmacdonald/purge/config"
+	"github.com/jmacdonald/purge/filesystem/directory"
 	"github.com/jmacdonald/purge/filesystem/directory/navigator"
 	"github.com/jmacdonald/purge/input"
 	"github.com/jmacdonald/purge/view"
 )
 
+// Collects the values of a flag that can be passed more than once.
+type patternList []string
+
+// Implement flag.Value string function.
+func (patterns *patternList) String() string {
+	return strings.Join(*patterns, ", ")
+}
+
+// Implement flag.Value set function, adding the value to the list.
+func (patterns *patternList) Set(pattern string) error {
+	*patterns = append(*patterns, pattern)
+	return nil
+}
+
 func main() {
 	// Use all available "logical CPUs", as reported by the machine.
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	var excludes patternList
+	flag.Var(&excludes, "exclude", "exclude entries matching a gitignore-style `pattern` from scans (repeatable)")
+	hideHidden := flag.Bool("hide-hidden", false, "leave hidden entries (dotfiles) out of listings")
+	flag.Parse()
+
 	// Determine in which directory to start,
 	// validating the path if passed by the user.
 	var startingPath string
-	if len(os.Args) > 1 {
-		startingPath = os.Args[1]
+	if flag.NArg() > 0 {
+		startingPath = flag.Arg(0)
 
 		// Check that the specified directory exists.
 		path, error := os.Stat(startingPath)
@@ -28,12 +53,93 @@ func main() {
 		}
 	} else {
 		// Fall back to the current directory.
-		var err error
-		startingPath, err = os.Getwd()
-		if err != nil {
-			panic(err)
+		startingPath = "."
+	}
+
+	// Use an absolute path, since exclude patterns starting
+	// with a slash are matched against absolute paths.
+	startingPath, err := filepath.Abs(startingPath)
+	if err != nil {
+		panic(err)
+	}
+
+	// Load the user's configuration, applying their key map overrides.
+	configuration, err := config.Load(config.Path())
+	if err != nil {
+		fmt.Println("Can't load the configuration file:", err)
+		return
+	}
+	if err = input.Bind(configuration.Keys); err != nil {
+		fmt.Println("Invalid key map in the configuration file:", err)
+		return
+	}
+	if len(configuration.Columns) > 0 {
+		if err = view.SetColumns(configuration.Columns); err != nil {
+			fmt.Println("Invalid columns in the configuration file:", err)
+			return
+		}
+	}
+
+	// Pick a colour theme, respecting NO_COLOR (see no-color.org) unless
+	// the user has configured one explicitly. LS_COLORS applies to every
+	// theme but mono, and is overridden by any colours that are configured.
+	theme := configuration.Theme
+	if theme == "" {
+		theme = "dark"
+		if _, set := os.LookupEnv("NO_COLOR"); set {
+			theme = "mono"
 		}
 	}
+	if err = view.SetTheme(theme); err != nil {
+		fmt.Println("Invalid theme in the configuration file:", err)
+		return
+	}
+	if theme != "mono" {
+		view.ApplyLSColors(os.Getenv("LS_COLORS"))
+	}
+	if err = view.SetColours(configuration.Colours); err != nil {
+		fmt.Println("Invalid colours in the configuration file:", err)
+		return
+	}
+	if configuration.LargeSize > 0 {
+		view.LargeSize = configuration.LargeSize
+	}
+
+	// Log every deletion, so that there's a record of who removed what.
+	auditLog, err := audit.Open(configuration.AuditLogPath())
+	if err != nil {
+		fmt.Println("Can't open the audit log:", err)
+		return
+	}
+	defer auditLog.Close()
+	navigator.AuditLog = auditLog
+
+	navigator.WatchSubtree = configuration.WatchSubtree
+	if configuration.LargestFileCount > 0 {
+		navigator.LargestFileCount = configuration.LargestFileCount
+	}
+
+	// Leave excluded entries out of scans, using the patterns from both the
+	// configuration file and the command line.
+	exclude, err := directory.NewExcludeList(append(configuration.Exclude, excludes...))
+	if err != nil {
+		fmt.Println("Can't exclude entries:", err)
+		return
+	}
+	directory.SetExclude(exclude)
+
+	// Hide dotfiles if configured to, unless overridden on the command line.
+	navigator.HideHidden = configuration.HideHidden
+	flag.Visit(func(set *flag.Flag) {
+		if set.Name == "hide-hidden" {
+			navigator.HideHidden = *hideHidden
+		}
+	})
+
+	// Summarize the space freed once the view has been closed.
+	defer func() {
+		fmt.Print(navigator.Session.Summary())
+	}()
 
 	// Initialize (and schedule cleanup for) the view.
 	view.Initialize()
@@ -41,7 +147,7 @@ func main() {
 
 	// Create a command channel that we'll use
 	// to communicate with the navigator.
-	nav := make(chan string)
+	nav := make(chan input.Command)
 
 	// Create a buffer channel that the navigator will
 	// use to push updates to the view after state changes.
@@ -55,15 +161,20 @@ func main() {
 
 	// Listen for user input, relaying the
 	// appropriate commands to the navigator.
+	parser := new(input.Parser)
 	for {
 		// Read a character from STDIN.
 		character := input.Read(os.Stdin)
 
-		// Map the character to its corresponding command.
-		command := input.Map[character]
+		// Feed the character to the parser, which will map it (along with
+		// any preceding count or keys) to its corresponding command.
+		command, ok := parser.Parse(character)
+		if !ok {
+			continue
+		}
 
 		// Don't pass the quit command along, just exit the application loop.
-		if command == "Quit" {
+		if command.Name == "Quit" {
 			break
 		}
 
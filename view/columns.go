@@ -0,0 +1,91 @@
+package view
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Names of the columns that can be displayed alongside entry names.
+const (
+	SizeColumn        = "size"
+	PercentColumn     = "percent"
+	GraphColumn       = "graph"
+	ItemsColumn       = "items"
+	ModifiedColumn    = "modified"
+	UsedColumn        = "used"
+	OwnerColumn       = "owner"
+	GroupColumn       = "group"
+	PermissionsColumn = "permissions"
+	DiskUsageColumn   = "usage"
+)
+
+// Columns lists the columns displayed for each entry, in order.
+var Columns = []string{SizeColumn, PercentColumn, GraphColumn}
+
+// Structure describing how a column is laid out and populated.
+type columnDefinition struct {
+	width      int
+	alignRight bool
+	format     func(row Row) string
+}
+
+var columnDefinitions = map[string]columnDefinition{
+	SizeColumn: {14, true, func(row Row) string {
+		return row.Right
+	}},
+	PercentColumn: {PercentageWidth, true, func(row Row) string {
+		if !row.Details.Calculated || row.Details.Excluded {
+			return ""
+		}
+		return Percentage(row.Details.Fraction)
+	}},
+	GraphColumn: {GraphWidth, false, func(row Row) string {
+		if !row.Details.Calculated || row.Details.Excluded {
+			return ""
+		}
+		return Bar(row.Details.Fraction, GraphWidth)
+	}},
+	ItemsColumn: {8, true, func(row Row) string {
+		if !row.Details.Calculated || !row.Details.Mode.IsDir() {
+			return ""
+		}
+		return strconv.FormatInt(row.Details.Items, 10)
+	}},
+	ModifiedColumn: {16, false, func(row Row) string {
+		return row.Details.ModTime.Format("2006-01-02 15:04")
+	}},
+	UsedColumn: {16, false, func(row Row) string {
+		if !row.Details.Calculated || row.Details.LastUsed.IsZero() {
+			return ""
+		}
+		return row.Details.LastUsed.Format("2006-01-02 15:04")
+	}},
+	OwnerColumn: {8, false, func(row Row) string {
+		return row.Details.Owner
+	}},
+	GroupColumn: {8, false, func(row Row) string {
+		return row.Details.Group
+	}},
+	PermissionsColumn: {11, false, func(row Row) string {
+		return row.Details.Mode.String()
+	}},
+	DiskUsageColumn: {10, true, func(row Row) string {
+		if !row.Details.Calculated {
+			return ""
+		}
+		return Size(row.Details.DiskUsage)
+	}},
+}
+
+// SetColumns changes the columns displayed for each entry,
+// returning an error (and leaving them unchanged) if any are unknown.
+func SetColumns(names []string) error {
+	for _, name := range names {
+		if _, ok := columnDefinitions[name]; !ok {
+			return fmt.Errorf("view: unknown column %s", name)
+		}
+	}
+	Columns = names
+
+	return nil
+}
@@ -2,7 +2,9 @@ package view
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
 // Declare size interval constants.
@@ -14,6 +16,36 @@ const (
 	TB
 )
 
+// Widths of the formatted percentages and graph bars produced below.
+const (
+	PercentageWidth = 6
+	GraphWidth      = 12
+)
+
+// Given a fraction between 0 and 1, returns it
+// as a percentage with one decimal place (e.g. " 45.2%").
+func Percentage(fraction float64) string {
+	return fmt.Sprintf("%5.1f%%", fraction*100)
+}
+
+// Given a fraction between 0 and 1, returns a bar of the specified width
+// (including its brackets) filled proportionally, e.g. "[#####     ]".
+func Bar(fraction float64, width int) string {
+	interior := width - 2
+	if interior < 0 {
+		return ""
+	}
+
+	filled := int(math.Floor(fraction*float64(interior) + 0.5))
+	if filled > interior {
+		filled = interior
+	} else if filled < 0 {
+		filled = 0
+	}
+
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(" ", interior-filled) + "]"
+}
+
 // Given a size in bytes, generates a presentable string
 // in the unit most appropriate for the number of bytes.
 func Size(sizeInBytes int64) (formattedSize string) {
@@ -0,0 +1,178 @@
+package view
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+)
+
+// Renderer is implemented by the screens that the view draws on.
+// Cells are addressed by column and row, starting at the top left.
+type Renderer interface {
+	Init() error
+	Close()
+	Size() (width, height int)
+	Clear(style Style) error
+	SetCell(column, row int, character rune, style Style)
+	Flush() error
+}
+
+// Screen is the renderer used by the view. It defaults to the terminal,
+// and can be replaced (before calling Initialize) to render elsewhere.
+var Screen Renderer = TermboxScreen{}
+
+// TermboxScreen renders to the terminal, using termbox.
+type TermboxScreen struct{}
+
+// Init switches the terminal into termbox's full-screen mode.
+func (TermboxScreen) Init() error {
+	return termbox.Init()
+}
+
+// Close restores the terminal to its original state.
+func (TermboxScreen) Close() {
+	termbox.Close()
+}
+
+// Size returns the terminal's dimensions, in cells.
+func (TermboxScreen) Size() (width, height int) {
+	return termbox.Size()
+}
+
+// Clear fills termbox's back buffer with blank cells in the given style.
+func (TermboxScreen) Clear(style Style) error {
+	return termbox.Clear(style.Foreground, style.Background)
+}
+
+// SetCell draws the character in termbox's back buffer.
+func (TermboxScreen) SetCell(column, row int, character rune, style Style) {
+	termbox.SetCell(column, row, character, style.Foreground, style.Background)
+}
+
+// Flush draws termbox's back buffer to the terminal.
+func (TermboxScreen) Flush() error {
+	return termbox.Flush()
+}
+
+// Structure representing a single character cell on a MemoryScreen.
+type Cell struct {
+	Character rune
+	Style     Style
+}
+
+/*
+MemoryScreen is a renderer that draws to memory instead of a terminal,
+capturing each flushed frame as text. It's useful for testing (and
+scripting) purge without a terminal, and is safe for concurrent use.
+*/
+type MemoryScreen struct {
+	width, height int
+	cells         []Cell
+	frames        []string
+	mutex         sync.Mutex
+}
+
+// NewMemoryScreen returns a blank in-memory screen of the specified size.
+func NewMemoryScreen(width, height int) *MemoryScreen {
+	screen := &MemoryScreen{width: width, height: height}
+	screen.Clear(Style{})
+
+	return screen
+}
+
+// Init does nothing; memory screens are ready as soon as they're created.
+func (screen *MemoryScreen) Init() error {
+	return nil
+}
+
+// Close does nothing, leaving the captured frames available.
+func (screen *MemoryScreen) Close() {}
+
+// Size returns the dimensions the screen was created with.
+func (screen *MemoryScreen) Size() (width, height int) {
+	return screen.width, screen.height
+}
+
+// Clear blanks every cell, using the given style.
+func (screen *MemoryScreen) Clear(style Style) error {
+	screen.mutex.Lock()
+	defer screen.mutex.Unlock()
+
+	screen.cells = make([]Cell, screen.width*screen.height)
+	for index := range screen.cells {
+		screen.cells[index] = Cell{' ', style}
+	}
+
+	return nil
+}
+
+// SetCell stores the character; cells outside of the screen are ignored.
+func (screen *MemoryScreen) SetCell(column, row int, character rune, style Style) {
+	screen.mutex.Lock()
+	defer screen.mutex.Unlock()
+
+	if column < 0 || column >= screen.width || row < 0 || row >= screen.height {
+		return
+	}
+	screen.cells[row*screen.width+column] = Cell{character, style}
+}
+
+// Flush captures the screen's current contents as a frame.
+func (screen *MemoryScreen) Flush() error {
+	screen.mutex.Lock()
+	defer screen.mutex.Unlock()
+
+	screen.frames = append(screen.frames, screen.text())
+
+	return nil
+}
+
+// Cell returns the contents of the cell at the specified column and row.
+func (screen *MemoryScreen) Cell(column, row int) Cell {
+	screen.mutex.Lock()
+	defer screen.mutex.Unlock()
+
+	return screen.cells[row*screen.width+column]
+}
+
+// Frames returns the text of every frame flushed so far, oldest first.
+func (screen *MemoryScreen) Frames() []string {
+	screen.mutex.Lock()
+	defer screen.mutex.Unlock()
+
+	return append([]string(nil), screen.frames...)
+}
+
+// Frame returns the text of the most recently flushed
+// frame, or an empty string if nothing has been flushed.
+func (screen *MemoryScreen) Frame() string {
+	screen.mutex.Lock()
+	defer screen.mutex.Unlock()
+
+	if len(screen.frames) == 0 {
+		return ""
+	}
+
+	return screen.frames[len(screen.frames)-1]
+}
+
+// Returns the screen's contents as lines of text, with trailing spaces
+// removed. Like a terminal, wide characters cover the cell after them.
+func (screen *MemoryScreen) text() string {
+	lines := make([]string, screen.height)
+	for row := range lines {
+		var line []rune
+		for column := 0; column < screen.width; column++ {
+			character := screen.cells[row*screen.width+column].Character
+			line = append(line, character)
+			if runewidth.RuneWidth(character) == 2 {
+				column++
+			}
+		}
+		lines[row] = strings.TrimRight(string(line), " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
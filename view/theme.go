@@ -0,0 +1,322 @@
+package view
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Style pairs the foreground (including attributes, such
+// as bold) and background colours used to render text.
+type Style struct {
+	Foreground termbox.Attribute
+	Background termbox.Attribute
+}
+
+/*
+Theme defines the styles used to render the view.
+
+Normal is used for rows and as the base for every other row style; rows
+for directory entries use the style matching their type (or extension,
+for regular files), with Provisional (for partially calculated sizes),
+Large and Excluded (for entries excluded from scans) taking precedence.
+Extensions are keyed by lowercase extension, including the leading dot.
+Info and Failure are used for messages of the matching severity.
+*/
+type Theme struct {
+	Normal      Style
+	Status      Style
+	Directory   Style
+	Symlink     Style
+	Executable  Style
+	Error       Style
+	Large       Style
+	Provisional Style
+	Excluded    Style
+	Extensions  map[string]Style
+	Info        Style
+	Failure     Style
+}
+
+// Built-in themes, selectable by name.
+var Themes = map[string]Theme{
+	"dark": {
+		Normal:      Style{termbox.ColorWhite, termbox.ColorBlack},
+		Status:      Style{termbox.ColorBlack, termbox.ColorWhite},
+		Directory:   Style{Foreground: termbox.ColorYellow},
+		Symlink:     Style{Foreground: termbox.ColorCyan},
+		Executable:  Style{Foreground: termbox.ColorGreen},
+		Error:       Style{Foreground: termbox.ColorRed | termbox.AttrBold},
+		Large:       Style{Foreground: termbox.ColorRed},
+		Provisional: Style{Foreground: termbox.ColorBlue},
+		Excluded:    Style{Foreground: termbox.ColorBlack | termbox.AttrBold},
+		Info:        Style{termbox.ColorBlack, termbox.ColorGreen},
+		Failure:     Style{termbox.ColorWhite | termbox.AttrBold, termbox.ColorRed},
+	},
+	"light": {
+		Normal:      Style{termbox.ColorBlack, termbox.ColorWhite},
+		Status:      Style{termbox.ColorWhite, termbox.ColorBlue},
+		Directory:   Style{Foreground: termbox.ColorBlue | termbox.AttrBold},
+		Symlink:     Style{Foreground: termbox.ColorCyan},
+		Executable:  Style{Foreground: termbox.ColorGreen},
+		Error:       Style{Foreground: termbox.ColorRed | termbox.AttrBold},
+		Large:       Style{Foreground: termbox.ColorRed},
+		Provisional: Style{Foreground: termbox.ColorCyan},
+		Excluded:    Style{Foreground: termbox.ColorBlack | termbox.AttrBold},
+		Info:        Style{termbox.ColorWhite, termbox.ColorGreen},
+		Failure:     Style{termbox.ColorWhite | termbox.AttrBold, termbox.ColorRed},
+	},
+	// Uses the terminal's own colours, relying on attributes alone.
+	"mono": {
+		Status:    Style{Foreground: termbox.AttrReverse},
+		Directory: Style{Foreground: termbox.AttrBold},
+		Error:     Style{Foreground: termbox.AttrUnderline},
+		Info:      Style{Foreground: termbox.AttrReverse},
+		Failure:   Style{Foreground: termbox.AttrReverse | termbox.AttrBold | termbox.AttrUnderline},
+	},
+}
+
+// Masks off attributes (e.g. bold), leaving the colour.
+const colourMask = termbox.AttrBold - 1
+
+// ActiveTheme is the theme used to render the view.
+var ActiveTheme = Themes["dark"]
+
+// LargeSize is the size (in bytes) at which entries are styled as large.
+var LargeSize int64 = 1 << 30
+
+// SetTheme changes the active theme to the built-in theme with the given name.
+func SetTheme(name string) error {
+	theme, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("view: unknown theme %s", name)
+	}
+	ActiveTheme = theme
+
+	return nil
+}
+
+// Names of the colours and attributes accepted by ParseStyle.
+var colourNames = map[string]termbox.Attribute{
+	"default": termbox.ColorDefault,
+	"black":   termbox.ColorBlack,
+	"red":     termbox.ColorRed,
+	"green":   termbox.ColorGreen,
+	"yellow":  termbox.ColorYellow,
+	"blue":    termbox.ColorBlue,
+	"magenta": termbox.ColorMagenta,
+	"cyan":    termbox.ColorCyan,
+	"white":   termbox.ColorWhite,
+}
+var attributeNames = map[string]termbox.Attribute{
+	"bold":      termbox.AttrBold,
+	"underline": termbox.AttrUnderline,
+	"reverse":   termbox.AttrReverse,
+}
+
+// ParseStyle converts a description such as "yellow bold on blue"
+// (a foreground colour and attributes, optionally followed by
+// "on" and a background colour) into a style.
+func ParseStyle(description string) (style Style, err error) {
+	background := false
+	for _, word := range strings.Fields(strings.ToLower(description)) {
+		if colour, ok := colourNames[word]; ok {
+			if background {
+				style.Background = colour
+			} else {
+				style.Foreground = style.Foreground&^colourMask | colour
+			}
+		} else if attribute, ok := attributeNames[word]; ok && !background {
+			style.Foreground |= attribute
+		} else if word == "on" && !background {
+			background = true
+		} else {
+			return Style{}, fmt.Errorf("view: invalid style %q", description)
+		}
+	}
+
+	return style, nil
+}
+
+// SetColours overrides styles in the active theme. Keys are "normal", "status",
+// "directory", "symlink", "executable", "error", "large", "provisional",
+// "excluded", "info" and "failure", or extension patterns such as
+// "*.log"; values are parsed using ParseStyle.
+func SetColours(colours map[string]string) error {
+	for name, description := range colours {
+		style, err := ParseStyle(description)
+		if err != nil {
+			return err
+		}
+		if err = ActiveTheme.set(name, style); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Maps LS_COLORS keys to the names of the styles they set.
+var lsColorsNames = map[string]string{
+	"di": "directory",
+	"ln": "symlink",
+	"ex": "executable",
+	"or": "error",
+}
+
+/*
+ApplyLSColors applies styles defined using the LS_COLORS environment
+variable's format (e.g. "di=01;34:*.tar=31") to the active theme. Keys
+and codes that can't be represented are ignored.
+*/
+func ApplyLSColors(value string) {
+	for _, field := range strings.Split(value, ":") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name := parts[0]
+		if mappedName, ok := lsColorsNames[name]; ok {
+			name = mappedName
+		} else if !strings.HasPrefix(name, "*.") {
+			continue
+		}
+
+		ActiveTheme.set(name, parseSGR(parts[1]))
+	}
+}
+
+// Converts semicolon-separated SGR codes (e.g. "01;31") into a style.
+func parseSGR(codes string) (style Style) {
+	for _, field := range strings.Split(codes, ";") {
+		code, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case code == 1:
+			style.Foreground |= termbox.AttrBold
+		case code == 4:
+			style.Foreground |= termbox.AttrUnderline
+		case code == 7:
+			style.Foreground |= termbox.AttrReverse
+		case code >= 30 && code <= 37:
+			style.Foreground = style.Foreground&^colourMask | termbox.Attribute(code-30) + termbox.ColorBlack
+		case code >= 40 && code <= 47:
+			style.Background = termbox.Attribute(code-40) + termbox.ColorBlack
+		}
+	}
+
+	return
+}
+
+// Sets the named style.
+func (theme *Theme) set(name string, style Style) error {
+	switch name {
+	case "normal":
+		theme.Normal = style
+	case "status":
+		theme.Status = style
+	case "directory":
+		theme.Directory = style
+	case "symlink":
+		theme.Symlink = style
+	case "executable":
+		theme.Executable = style
+	case "error":
+		theme.Error = style
+	case "large":
+		theme.Large = style
+	case "provisional":
+		theme.Provisional = style
+	case "excluded":
+		theme.Excluded = style
+	case "info":
+		theme.Info = style
+	case "failure":
+		theme.Failure = style
+	default:
+		if !strings.HasPrefix(name, "*.") {
+			return fmt.Errorf("view: unknown style %s", name)
+		}
+
+		// Copy the extensions before changing them, since
+		// they may be shared with one of the built-in themes.
+		extensions := make(map[string]Style, len(theme.Extensions)+1)
+		for extension, extensionStyle := range theme.Extensions {
+			extensions[extension] = extensionStyle
+		}
+		extensions[strings.ToLower(name[1:])] = style
+		theme.Extensions = extensions
+	}
+
+	return nil
+}
+
+// Returns the style used to render the row.
+func (theme Theme) rowStyle(row Row) Style {
+	style := theme.Normal
+
+	if row.Details != nil {
+		switch row.Type {
+		case DirectoryRow:
+			style = style.merge(theme.Directory)
+		case SymlinkRow:
+			style = style.merge(theme.Symlink)
+		case ErrorRow:
+			style = style.merge(theme.Error)
+		default:
+			if extensionStyle, ok := theme.Extensions[strings.ToLower(filepath.Ext(row.Left))]; ok {
+				style = style.merge(extensionStyle)
+			} else if row.Details.Mode&0111 != 0 {
+				style = style.merge(theme.Executable)
+			}
+		}
+
+		if row.Details.Provisional {
+			style = style.merge(theme.Provisional)
+		}
+		if row.Details.Size >= LargeSize {
+			style = style.merge(theme.Large)
+		}
+		if row.Details.Excluded {
+			style = style.merge(theme.Excluded)
+		}
+	}
+
+	// Invert the row's colours to highlight it.
+	if row.Highlight {
+		style.Foreground ^= termbox.AttrReverse
+	}
+
+	return style
+}
+
+// Returns the style used to render messages with the given severity.
+func (theme Theme) messageStyle(severity Severity) Style {
+	switch severity {
+	case Failure:
+		return theme.Failure
+	}
+
+	return theme.Info
+}
+
+// Returns the style with any colours or
+// attributes set in the other style applied to it.
+func (style Style) merge(other Style) Style {
+	if other.Foreground&colourMask != termbox.ColorDefault {
+		style.Foreground = style.Foreground&^colourMask | other.Foreground&colourMask
+	}
+	style.Foreground |= other.Foreground &^ colourMask
+	if other.Background != termbox.ColorDefault {
+		style.Background = other.Background
+	}
+
+	return style
+}
@@ -4,34 +4,90 @@ data formatting and display updates (using termbox).
 */
 package view
 
-import "fmt"
+import "os"
 import "strings"
-import "github.com/nsf/termbox-go"
-import "unicode/utf8"
+import "time"
+import "github.com/mattn/go-runewidth"
 
 // Buffer encapsulates all of the data required to render the view.
+// A message, if present, is displayed in place of the status line's left side.
 type Buffer struct {
-	Rows   []Row
-	Status [2]string
+	Rows    []Row
+	Status  [2]string
+	Message *Message
 }
 
+// Message is a notification displayed in the status line.
+type Message struct {
+	Text     string
+	Severity Severity
+}
+
+// Severities of messages, which are styled differently.
+type Severity int
+
+const (
+	Info Severity = iota
+	Failure
+)
+
 /*
 Encapsulates information require to draw a row of information.
 
 Left and right represent two columns with matching alignment.
+Rows describing directory entries also carry Details; for those rows,
+Left is followed by the configured columns (see Columns), and Right is
+displayed in the size column. Type is used to style the row.
 Highlight inverts the row's colours, useful for "selecting" a row.
 */
 type Row struct {
 	Left      string
 	Right     string
 	Highlight bool
-	Colour    bool
+	Type      RowType
+	Details   *Details
+}
+
+// Types of entries that rows can represent, which are styled differently.
+type RowType int
+
+const (
+	FileRow RowType = iota
+	DirectoryRow
+	SymlinkRow
+	ErrorRow
+)
+
+/*
+Details holds the entry information displayed in columns other than the size.
+
+Fraction is the entry's share of its parent directory's total size, between
+0 and 1. Calculated is false while directory sizes are being calculated,
+leaving the columns that depend on them (fraction, items, disk usage, last
+use) blank. LastUsed is the newest modification or access time within the entry.
+Provisional is set while the size is a partial total, which is still growing.
+Excluded is set for entries excluded from scans, which don't count towards
+their parent directory's total (leaving the fraction, percentage and graph blank).
+*/
+type Details struct {
+	Size        int64
+	Fraction    float64
+	Items       int64
+	DiskUsage   int64
+	ModTime     time.Time
+	LastUsed    time.Time
+	Owner       string
+	Group       string
+	Mode        os.FileMode
+	Calculated  bool
+	Provisional bool
+	Excluded    bool
 }
 
 // Initialize prepares the screen for rendering, and should
 // only be run once, before constructing a new view.
 func Initialize() {
-	err := termbox.Init()
+	err := Screen.Init()
 	if err != nil {
 		panic(err)
 	}
@@ -40,21 +96,24 @@ func Initialize() {
 // Close is used to relinquish the screen so that
 // it can be used after the program exits.
 func Close() {
-	termbox.Close()
+	Screen.Close()
 }
 
 /*
-Construct a view that will listen for and render buffers sent to it.
-Initialize and Close must be called before and after this function,
-respectively.
+Construct a view that will listen for and render buffers sent to it,
+until the channel is closed. Initialize and Close must be called before
+and after this function, respectively.
 */
 func New(buffers <-chan *Buffer) {
 	for {
 		// Wait for a buffer.
-		buffer := <-buffers
+		buffer, ok := <-buffers
+		if !ok {
+			return
+		}
 
 		// Refresh the contents of the screen.
-		err := termbox.Clear(termbox.ColorWhite, termbox.ColorBlack)
+		err := Screen.Clear(ActiveTheme.Normal)
 		if err != nil {
 			return
 		}
@@ -65,81 +124,103 @@ func New(buffers <-chan *Buffer) {
 		}
 
 		// Render the source's status string.
-		renderStatus(buffer.Status)
+		renderStatus(buffer.Status, buffer.Message)
 
 		// Draw the contents to the screen.
-		termbox.Flush()
+		Screen.Flush()
 	}
 }
 
 // Render a single row of data to the screen.
 func renderRow(row Row, rowNumber int) {
-	width, _ := termbox.Size()
+	width, _ := Screen.Size()
 
 	// Format the row such that it fills the screen,
 	// and properly aligns the left/right columns.
-	formattedRow, err := FormatRow(row, width)
-
-	if err == nil {
-		// Step through the formatted row one rune at a time,
-		// printing the rune to the screen at the correct coordinates.
-		for column, character := range formattedRow {
-			fgColour, bgColour := termbox.ColorWhite, termbox.ColorBlack
+	style := ActiveTheme.rowStyle(row)
+	renderLine(FormatRow(row, width), 0, rowNumber, style)
+}
 
-			if row.Highlight {
-				fgColour, bgColour = bgColour, fgColour
-			}
-			if row.Colour {
-				fgColour = termbox.ColorYellow
-			}
+// Render a status message to the bottom of the screen. If there's a
+// message, it's displayed in place of the left side of the status.
+func renderStatus(status [2]string, message *Message) {
+	width, height := Screen.Size()
 
-			termbox.SetCell(column, rowNumber, character, fgColour, bgColour)
-		}
+	leftStyle := ActiveTheme.Status
+	if message != nil {
+		status[0] = message.Text
+		leftStyle = ActiveTheme.messageStyle(message.Severity)
 	}
-}
-
-// Render a status message to the bottom of the screen.
-func renderStatus(status [2]string) {
-	width, height := termbox.Size()
 
 	// The status line components may be too long to fit on-screen. If that's the case,
 	// we'll trim the left side of the path, since it's the least important
 	// piece of information of the bunch.
-	maximumLeftSideWidth := width - len(status[1]) - 1
-	if len(status[0]) > maximumLeftSideWidth {
-		// Figure out how much of a character surplus we have.
-		excess := len(status[0]) - maximumLeftSideWidth
+	// If the right side doesn't leave any room, the left side is left out.
+	maximumLeftSideWidth := width - Width(status[1]) - 1
+	if maximumLeftSideWidth < 0 {
+		maximumLeftSideWidth = 0
+	}
+	if Width(status[0]) > maximumLeftSideWidth {
+		if message != nil || maximumLeftSideWidth <= len(ellipsis) {
+			// Messages read from the left, so trim their end instead
+			// (as with paths, when there's only room for an ellipsis).
+			status[0] = Truncate(status[0], maximumLeftSideWidth)
+		} else {
+			// Trim the leading part of the path, adding an elipsis.
+			status[0] = ellipsis + cutLeft(status[0], maximumLeftSideWidth-len(ellipsis))
+		}
+	}
 
-		// Trim the leading part of the path, adding an elipsis.
-		status[0] = "..." + status[0][excess+3:]
+	// Print the status to the bottom of the screen in a highlighted colour,
+	// padding the left side with spaces so that the right side is aligned.
+	column := renderText(status[0], 0, height-1, leftStyle)
+	renderLine(padding(maximumLeftSideWidth-column)+" "+status[1], column, height-1, ActiveTheme.Status)
+}
+
+// Render text to a row of the screen, starting at the specified column and
+// filling the rest of the row with spaces.
+func renderLine(text string, column, rowNumber int, style Style) {
+	width, _ := Screen.Size()
+
+	for column = renderText(text, column, rowNumber, style); column < width; column++ {
+		Screen.SetCell(column, rowNumber, ' ', style)
 	}
+}
 
-	// Build a string representing the status line contents, padding with spaces.
-	padding := strings.Repeat(" ", (maximumLeftSideWidth-len(status[0]))) + " "
-	statusLineContents := status[0] + padding + status[1]
-
-	// Print the status to the bottom of the screen by stepping
-	// through the bottom row one cell at a time and printing
-	// a character from the status message, or a blank space,
-	// until all of the row has been filled.
-	for column, offset := 0, 0; column < width; column++ {
-		var character rune
-		var size int
-
-		// Decode the next rune and advance the offset by its length,
-		// or if we've already read the entire string, use a space instead.
-		character, size = utf8.DecodeRune([]byte(statusLineContents)[offset:])
-		offset += size
-
-		// Print the character to the screen in a highlighted colour.
-		termbox.SetCell(column, height-1, character, termbox.ColorBlack, termbox.ColorWhite)
+// Render text to a row of the screen, starting at the specified column, and
+// return the column following it. Wide characters take up two cells. Cells
+// hold a single character, so combining marks (which don't take up any space
+// of their own) are dropped.
+func renderText(text string, column, rowNumber int, style Style) int {
+	width, _ := Screen.Size()
+
+	for _, character := range text {
+		characterWidth := runewidth.RuneWidth(character)
+		if characterWidth == 0 {
+			continue
+		}
+		if column+characterWidth > width {
+			break
+		}
+
+		Screen.SetCell(column, rowNumber, character, style)
+		column += characterWidth
 	}
+
+	return column
+}
+
+// ScreenWidth returns the width of the screen, in columns.
+func ScreenWidth() int {
+	width, _ := Screen.Size()
+
+	return width
 }
 
 func Height() int {
 	// Return a height one row smaller than the screen
 	// height, so that we have room to render a status bar.
-	_, height := termbox.Size()
+	_, height := Screen.Size()
 
 	// If for some reason the height is zero or less,
 	// just return zero to prevent runtime panics.
@@ -153,22 +234,95 @@ func Height() int {
 /*
 FormatRow returns a string with the row's left/right
 elements placed at the far left/right with spaces in between.
+Rows with details have their left element followed by the
+configured columns instead. If space is tight, the left
+element is truncated to make room for the other columns.
 */
-func FormatRow(row Row, size int) (string, error) {
-	// Figure out how large the left field needs to be, including
-	// padding, to have the right field properly aligned to size.
-	leftSize := size - len(row.Right)
-
-	// Don't bother trying to format this row if the left and
-	// right columns can't be separated by at least one space.
-	if leftSize <= len(row.Left) {
-		return "", fmt.Errorf("view: formatting row to a size of %d"+
-			" with '%s' and '%s' values is impossible", size, row.Left, row.Right)
+func FormatRow(row Row, size int) string {
+	columns := []Column{{Text: row.Left}}
+	if row.Details != nil {
+		for _, name := range Columns {
+			definition := columnDefinitions[name]
+			columns = append(columns, Column{Text: definition.format(row),
+				Width: definition.width, AlignRight: definition.alignRight})
+		}
+	} else if row.Right != "" {
+		columns = append(columns, Column{Text: row.Right, Width: Width(row.Right), AlignRight: true})
 	}
 
-	// Generate a format string with the appropriate spacing.
-	formatString := fmt.Sprintf("%%-%ds%%s", leftSize)
+	return FormatColumns(columns, size)
+}
 
-	// Generate and return the formatted row.
-	return fmt.Sprintf(formatString, row.Left, row.Right), nil
+// Column describes a single field within a formatted row. Columns with a
+// width of zero are flexible, sharing whatever space the others leave over.
+type Column struct {
+	Text       string
+	Width      int
+	AlignRight bool
 }
+
+/*
+FormatColumns lays the columns out from left to right, separated by a single
+space, such that they fill size characters. Text is padded to fill its
+column, aligned to the right if requested, or truncated with an ellipsis
+if it's too wide. If the columns can't all fit, the result is cut short.
+*/
+func FormatColumns(columns []Column, size int) string {
+	// Figure out how much space is left for flexible columns.
+	var flexibleCount int
+	remaining := size - (len(columns) - 1)
+	for _, column := range columns {
+		if column.Width == 0 {
+			flexibleCount++
+		} else {
+			remaining -= column.Width
+		}
+	}
+
+	// Divide the remaining space evenly between flexible
+	// columns, giving the first ones any leftover characters.
+	var flexibleWidth, leftover int
+	if flexibleCount > 0 && remaining > 0 {
+		flexibleWidth, leftover = remaining/flexibleCount, remaining%flexibleCount
+	}
+
+	formattedColumns := make([]string, len(columns))
+	for index, column := range columns {
+		width := column.Width
+		if width == 0 {
+			width = flexibleWidth
+			if leftover > 0 {
+				width++
+				leftover--
+			}
+		}
+
+		text := Truncate(column.Text, width)
+		if column.AlignRight {
+			formattedColumns[index] = padding(width-Width(text)) + text
+		} else {
+			formattedColumns[index] = text + padding(width-Width(text))
+		}
+	}
+
+	return cut(strings.Join(formattedColumns, " "), size)
+}
+
+// Truncate shortens text to the specified width (in columns), if necessary,
+// replacing its last characters with an ellipsis. Nothing fits in a width of
+// zero (or less), so an empty string is returned.
+func Truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if Width(text) <= width {
+		return text
+	}
+	if width < len(ellipsis)+1 {
+		return strings.Repeat(".", width)
+	}
+
+	return cut(text, width-len(ellipsis)) + ellipsis
+}
+
+const ellipsis = "..."
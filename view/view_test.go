@@ -1,9 +1,11 @@
 package view
 
 import (
-	"fmt"
+	"github.com/nsf/termbox-go"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -14,16 +16,12 @@ func TestView(t *testing.T) {
 
 var _ = Describe("View", func() {
 	Describe("FormatRow", func() {
-		var result, errorMessage string
-		var err error
+		var result string
 		var row Row
 		var size int
 
 		JustBeforeEach(func() {
-			errorMessage = fmt.Sprintf("view: formatting row to a size of %d"+
-				" with '%s' and '%s' values is impossible", size, row.Left, row.Right)
-
-			result, err = FormatRow(row, size)
+			result = FormatRow(row, size)
 		})
 
 		Context("row values are set and size is larger than their sum", func() {
@@ -38,40 +36,58 @@ var _ = Describe("View", func() {
 		})
 
 		Context("row values are set and size is equal to their sum", func() {
+			BeforeEach(func() {
+				row = Row{Left: "left value", Right: "right"}
+				size = 13
+			})
+
+			It("truncates the left value with an ellipsis", func() {
+				Expect(result).To(Equal("left... right"))
+			})
+		})
+
+		Context("row values are set and size is smaller than the right value", func() {
 			BeforeEach(func() {
 				row = Row{Left: "left", Right: "right"}
-				size = 9
+				size = 5
 			})
 
-			It("returns an empty string", func() {
-				Expect(result).To(Equal(""))
+			It("cuts the row short", func() {
+				Expect(result).To(Equal(" righ"))
 			})
+		})
 
-			It("returns an error", func() {
-				Expect(err).ToNot(BeNil())
+		Context("left value contains wide characters", func() {
+			BeforeEach(func() {
+				row = Row{Left: "日本語", Right: "right"}
+				size = 14
 			})
 
-			It("returns the proper error message", func() {
-				Expect(err.Error()).To(Equal(errorMessage))
+			It("pads the row using their display width", func() {
+				Expect(result).To(Equal("日本語   right"))
+				Expect(Width(result)).To(Equal(14))
 			})
 		})
 
-		Context("row values are set and size is smaller than their sum", func() {
+		Context("left value contains wide characters that don't fit", func() {
 			BeforeEach(func() {
-				row = Row{Left: "left", Right: "right"}
-				size = 5
+				row = Row{Left: "日本語のファイル", Right: "right"}
+				size = 14
 			})
 
-			It("returns an empty string", func() {
-				Expect(result).To(Equal(""))
+			It("truncates the left value at a character boundary", func() {
+				Expect(result).To(Equal("日本...  right"))
 			})
+		})
 
-			It("returns an error", func() {
-				Expect(err.Error()).ToNot(BeNil())
+		Context("left value contains combining marks", func() {
+			BeforeEach(func() {
+				row = Row{Left: "cafe\u0301", Right: "right"}
+				size = 10
 			})
 
-			It("returns the proper error message", func() {
-				Expect(err.Error()).To(Equal(errorMessage))
+			It("doesn't count them towards the width", func() {
+				Expect(result).To(Equal("cafe\u0301 right"))
 			})
 		})
 
@@ -85,6 +101,364 @@ var _ = Describe("View", func() {
 				Expect(result).To(Equal("     right"))
 			})
 		})
+
+		Context("row has details", func() {
+			var originalColumns []string
+
+			BeforeEach(func() {
+				originalColumns = Columns
+				row = Row{Left: "left", Right: "1.0 KB", Details: &Details{Fraction: 0.5, Items: 3,
+					Mode: os.ModeDir | 0755, Owner: "owner", Group: "group", Calculated: true}}
+				size = 50
+			})
+
+			AfterEach(func() {
+				Columns = originalColumns
+			})
+
+			It("lays out the default columns", func() {
+				Expect(result).To(Equal("left" + strings.Repeat(" ", 11) + "         1.0 KB  50.0% [#####     ]"))
+			})
+
+			Context("columns have been configured", func() {
+				BeforeEach(func() {
+					SetColumns([]string{ItemsColumn, OwnerColumn, GroupColumn, PermissionsColumn})
+					size = 49
+				})
+
+				It("lays out the configured columns in order", func() {
+					Expect(result).To(Equal("left      " + "        3" + " owner   " + " group   " + " drwxr-xr-x "))
+				})
+			})
+
+			Context("the entry is excluded", func() {
+				BeforeEach(func() {
+					row.Details.Excluded = true
+				})
+
+				It("leaves the percentage and graph blank", func() {
+					Expect(result).To(Equal("left" + strings.Repeat(" ", 11) + "         1.0 KB" + strings.Repeat(" ", 20)))
+				})
+			})
+
+			Context("sizes are still being calculated", func() {
+				BeforeEach(func() {
+					row.Right = "Calculating..."
+					row.Details.Calculated = false
+				})
+
+				It("leaves the percentage and graph blank", func() {
+					Expect(result).To(Equal("left" + strings.Repeat(" ", 11) + " Calculating..." + strings.Repeat(" ", 20)))
+				})
+			})
+		})
+	})
+
+	Describe("SetColumns", func() {
+		It("returns an error for unknown columns", func() {
+			Expect(SetColumns([]string{"size", "colour"})).ToNot(BeNil())
+			Expect(Columns).ToNot(ContainElement("colour"))
+		})
+	})
+
+	Describe("FormatColumns", func() {
+		var result string
+		var columns []Column
+		var size int
+
+		JustBeforeEach(func() {
+			result = FormatColumns(columns, size)
+		})
+
+		Context("columns have fixed widths", func() {
+			BeforeEach(func() {
+				columns = []Column{{Text: "a", Width: 3}, {Text: "b", Width: 4, AlignRight: true}}
+				size = 8
+			})
+
+			It("pads and aligns each column", func() {
+				Expect(result).To(Equal("a      b"))
+			})
+		})
+
+		Context("there are several flexible columns", func() {
+			BeforeEach(func() {
+				columns = []Column{{Text: "a"}, {Text: "b", Width: 1}, {Text: "c"}}
+				size = 10
+			})
+
+			It("shares the remaining space between them", func() {
+				Expect(result).To(Equal("a    b c  "))
+			})
+		})
+
+		Context("a column's text is too wide", func() {
+			BeforeEach(func() {
+				columns = []Column{{Text: "abcdef", Width: 5}, {Text: "b", Width: 1}}
+				size = 7
+			})
+
+			It("truncates the text with an ellipsis", func() {
+				Expect(result).To(Equal("ab... b"))
+			})
+		})
+	})
+
+	Describe("Truncate", func() {
+		It("leaves short text alone", func() {
+			Expect(Truncate("abc", 3)).To(Equal("abc"))
+		})
+
+		It("replaces the end of long text with an ellipsis", func() {
+			Expect(Truncate("abcdef", 5)).To(Equal("ab..."))
+		})
+
+		It("uses dots alone when there isn't room for any text", func() {
+			Expect(Truncate("abcdef", 2)).To(Equal(".."))
+		})
+
+		It("returns nothing when there's no room at all", func() {
+			Expect(Truncate("abcdef", 0)).To(BeEmpty())
+			Expect(Truncate("abcdef", -3)).To(BeEmpty())
+		})
+
+		It("measures wide characters by their display width", func() {
+			Expect(Truncate("日本語", 6)).To(Equal("日本語"))
+			Expect(Truncate("日本語", 5)).To(Equal("日..."))
+			Expect(Truncate("日本語", 4)).To(Equal("..."))
+		})
+
+		It("keeps combining marks with the characters they modify", func() {
+			Expect(Truncate("e\u0301e\u0301e\u0301e\u0301e\u0301", 4)).To(Equal("e\u0301..."))
+		})
+	})
+
+	Describe("Width", func() {
+		It("counts East Asian wide characters as two columns", func() {
+			Expect(Width("a日本")).To(Equal(5))
+		})
+
+		It("counts combining marks as zero columns", func() {
+			Expect(Width("cafe\u0301")).To(Equal(4))
+		})
+	})
+
+	Describe("cutLeft", func() {
+		It("keeps the longest end of the text that fits", func() {
+			Expect(cutLeft("/home/日本語", 5)).To(Equal("本語"))
+		})
+
+		It("doesn't split combining marks from their characters", func() {
+			Expect(cutLeft("/cafe\u0301", 1)).To(Equal("e\u0301"))
+			Expect(cutLeft("e\u0301\u0301", 0)).To(Equal(""))
+		})
+	})
+})
+
+var _ = Describe("MemoryScreen", func() {
+	var screen *MemoryScreen
+
+	BeforeEach(func() {
+		screen = NewMemoryScreen(6, 2)
+	})
+
+	It("captures flushed frames as text", func() {
+		screen.SetCell(0, 0, 'a', Style{})
+		screen.Flush()
+		screen.SetCell(1, 1, 'b', Style{})
+		screen.Flush()
+
+		Expect(screen.Frames()).To(Equal([]string{"a\n", "a\n b"}))
+		Expect(screen.Frame()).To(Equal("a\n b"))
+	})
+
+	It("lets wide characters cover the following cell", func() {
+		screen.SetCell(0, 0, '日', Style{})
+		screen.SetCell(2, 0, 'a', Style{})
+		screen.Flush()
+
+		Expect(screen.Frame()).To(Equal("日a\n"))
+	})
+
+	It("ignores cells outside of the screen", func() {
+		Expect(func() { screen.SetCell(6, 2, 'a', Style{}) }).ToNot(Panic())
+	})
+})
+
+var _ = Describe("New", func() {
+	var screen *MemoryScreen
+	var originalScreen Renderer
+
+	BeforeEach(func() {
+		originalScreen = Screen
+		screen = NewMemoryScreen(20, 3)
+		Screen = screen
+	})
+
+	AfterEach(func() {
+		Screen = originalScreen
+	})
+
+	It("renders buffers to the screen until the channel is closed", func(done Done) {
+		buffers := make(chan *Buffer)
+		go func() {
+			buffers <- &Buffer{Rows: []Row{{Left: "日本語", Right: "1 KB", Highlight: true}},
+				Status: [2]string{"/home/user/日本語", "50%"}}
+			close(buffers)
+		}()
+		New(buffers)
+
+		Expect(screen.Frame()).To(Equal("日本語          1 KB\n\n...e/user/日本語 50%"))
+		Expect(screen.Cell(0, 0).Style).To(Equal(ActiveTheme.rowStyle(Row{Highlight: true})))
+		Expect(screen.Cell(0, 2).Style).To(Equal(ActiveTheme.Status))
+		close(done)
+	})
+
+	It("displays messages in place of the status line's left side", func(done Done) {
+		buffers := make(chan *Buffer)
+		go func() {
+			buffers <- &Buffer{Status: [2]string{"/home/user", "50%"},
+				Message: &Message{Text: "Permission denied: important", Severity: Failure}}
+			close(buffers)
+		}()
+		New(buffers)
+
+		Expect(screen.Frame()).To(Equal("\n\nPermission de... 50%"))
+		Expect(screen.Cell(0, 2).Style).To(Equal(ActiveTheme.Failure))
+		Expect(screen.Cell(16, 2).Style).To(Equal(ActiveTheme.Status))
+		close(done)
+	})
+
+	It("leaves the left side out when the right side is wider than the screen", func(done Done) {
+		buffers := make(chan *Buffer)
+		go func() {
+			buffers <- &Buffer{Status: [2]string{"/home/user", "[size desc]  12.0 GB available (50% used)"},
+				Message: &Message{Text: "Deleted foo", Severity: Info}}
+			buffers <- &Buffer{Status: [2]string{"/home/user", "[size desc]  12.0 GB available (50% used)"}}
+			close(buffers)
+		}()
+		New(buffers)
+
+		Expect(screen.Frames()).To(Equal([]string{"\n\n [size desc]  12.0 G", "\n\n [size desc]  12.0 G"}))
+		close(done)
+	})
+})
+
+var _ = Describe("Theme", func() {
+	var originalTheme Theme
+
+	BeforeEach(func() {
+		originalTheme = ActiveTheme
+	})
+
+	AfterEach(func() {
+		ActiveTheme = originalTheme
+	})
+
+	Describe("SetTheme", func() {
+		It("activates built-in themes", func() {
+			Expect(SetTheme("light")).To(BeNil())
+			Expect(ActiveTheme.Normal).To(Equal(Themes["light"].Normal))
+		})
+
+		It("rejects unknown themes", func() {
+			Expect(SetTheme("neon")).ToNot(BeNil())
+		})
+	})
+
+	Describe("ParseStyle", func() {
+		It("parses foreground colours and attributes", func() {
+			style, err := ParseStyle("Yellow bold")
+			Expect(err).To(BeNil())
+			Expect(style).To(Equal(Style{Foreground: termbox.ColorYellow | termbox.AttrBold}))
+		})
+
+		It("parses background colours", func() {
+			style, err := ParseStyle("white on blue")
+			Expect(err).To(BeNil())
+			Expect(style).To(Equal(Style{termbox.ColorWhite, termbox.ColorBlue}))
+		})
+
+		It("rejects unknown words", func() {
+			_, err := ParseStyle("sparkly")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("SetColours", func() {
+		It("overrides named styles and extensions", func() {
+			Expect(SetColours(map[string]string{"directory": "blue", "*.LOG": "red"})).To(BeNil())
+			Expect(ActiveTheme.Directory).To(Equal(Style{Foreground: termbox.ColorBlue}))
+			Expect(ActiveTheme.Extensions[".log"]).To(Equal(Style{Foreground: termbox.ColorRed}))
+		})
+
+		It("leaves the built-in themes alone", func() {
+			SetColours(map[string]string{"*.log": "red"})
+			Expect(Themes["dark"].Extensions).To(BeEmpty())
+		})
+
+		It("rejects unknown style names", func() {
+			Expect(SetColours(map[string]string{"sidebar": "blue"})).ToNot(BeNil())
+		})
+	})
+
+	Describe("ApplyLSColors", func() {
+		It("applies supported keys and extensions", func() {
+			ApplyLSColors("rs=0:di=01;34:ln=36:*.tar=31;42:bd=40;33")
+			Expect(ActiveTheme.Directory).To(Equal(Style{Foreground: termbox.ColorBlue | termbox.AttrBold}))
+			Expect(ActiveTheme.Symlink).To(Equal(Style{Foreground: termbox.ColorCyan}))
+			Expect(ActiveTheme.Extensions[".tar"]).To(Equal(Style{termbox.ColorRed, termbox.ColorGreen}))
+		})
+	})
+
+	Describe("rowStyle", func() {
+		var row Row
+
+		BeforeEach(func() {
+			ActiveTheme = Themes["dark"]
+			row = Row{Left: "file", Details: &Details{Mode: 0644}}
+		})
+
+		It("uses the normal style for regular files", func() {
+			Expect(ActiveTheme.rowStyle(row)).To(Equal(ActiveTheme.Normal))
+		})
+
+		It("colours directories", func() {
+			row.Type = DirectoryRow
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorYellow))
+		})
+
+		It("colours executables", func() {
+			row.Details.Mode = 0755
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorGreen))
+		})
+
+		It("colours files by extension", func() {
+			SetColours(map[string]string{"*.log": "blue"})
+			row.Left = "debug.LOG"
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorBlue))
+		})
+
+		It("colours provisional sizes", func() {
+			row.Details.Provisional = true
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorBlue))
+		})
+
+		It("greys out excluded entries, even if they're large", func() {
+			row.Details.Excluded = true
+			row.Details.Size = LargeSize
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorBlack | termbox.AttrBold))
+		})
+
+		It("colours large entries", func() {
+			row.Details.Size = LargeSize
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorRed))
+		})
+
+		It("reverses highlighted rows", func() {
+			row.Highlight = true
+			Expect(ActiveTheme.rowStyle(row).Foreground).To(Equal(termbox.ColorWhite | termbox.AttrReverse))
+		})
 	})
 })
 
@@ -92,6 +466,30 @@ var _ = Describe("Format", func() {
 	var output string
 	var input int64
 
+	Describe("Percentage", func() {
+		It("formats the fraction with one decimal place", func() {
+			Expect(Percentage(0.4567)).To(Equal(" 45.7%"))
+		})
+
+		It("fits a full percentage in the same width", func() {
+			Expect(len(Percentage(1))).To(Equal(PercentageWidth))
+		})
+	})
+
+	Describe("Bar", func() {
+		It("fills the bar proportionally", func() {
+			Expect(Bar(0.25, 10)).To(Equal("[##      ]"))
+		})
+
+		It("fills the entire bar for a whole fraction", func() {
+			Expect(Bar(1, 6)).To(Equal("[####]"))
+		})
+
+		It("leaves the bar empty for a zero fraction", func() {
+			Expect(Bar(0, 6)).To(Equal("[    ]"))
+		})
+	})
+
 	Describe("Size", func() {
 		JustBeforeEach(func() {
 			output = Size(input)
@@ -0,0 +1,50 @@
+package view
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Width returns the number of terminal columns needed to display the text.
+// East Asian wide characters take up two columns, and combining marks none.
+func Width(text string) int {
+	return runewidth.StringWidth(text)
+}
+
+// Returns the longest beginning of the text that fits within width columns.
+func cut(text string, width int) string {
+	var used int
+	for index, character := range text {
+		used += runewidth.RuneWidth(character)
+		if used > width {
+			return text[:index]
+		}
+	}
+
+	return text
+}
+
+// Returns the longest end of the text that fits within width columns,
+// without leaving combining marks separated from the characters they modify.
+func cutLeft(text string, width int) string {
+	for text != "" {
+		character, size := utf8.DecodeRuneInString(text)
+		if Width(text) <= width && runewidth.RuneWidth(character) > 0 {
+			break
+		}
+		text = text[size:]
+	}
+
+	return text
+}
+
+// Returns enough spaces to fill width columns.
+func padding(width int) string {
+	if width < 0 {
+		return ""
+	}
+
+	return strings.Repeat(" ", width)
+}